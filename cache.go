@@ -1,6 +1,81 @@
 package bahn
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrCacheMiss = errors.New("cache miss")
+
 type CacheBackend interface {
 	Set(key string, value interface{}) error
 	Get(key string, value interface{}) error
 }
+
+// ContextCacheBackend can optionally be implemented by a CacheBackend to have
+// the caller's deadline and cancellation passed through to lookups and stores.
+type ContextCacheBackend interface {
+	CacheBackend
+	SetContext(ctx context.Context, key string, value interface{}) error
+	GetContext(ctx context.Context, key string, value interface{}) error
+}
+
+// ExpiringCacheBackend can optionally be implemented by a CacheBackend to
+// receive the time-to-live of each entry. GetEntry only returns expired
+// entries if allowStale is set, the returned metadata tells them apart.
+type ExpiringCacheBackend interface {
+	CacheBackend
+	SetEntry(ctx context.Context, key string, value interface{}, metadata CacheMetadata) error
+	GetEntry(ctx context.Context, key string, value interface{}, allowStale bool) (CacheMetadata, error)
+}
+
+type CacheMetadata struct {
+	StoredAt time.Time
+	TTL      time.Duration
+}
+
+func (m CacheMetadata) ExpiresAt() time.Time {
+	if m.StoredAt.IsZero() || m.TTL <= 0 {
+		return time.Time{}
+	}
+	return m.StoredAt.Add(m.TTL)
+}
+
+func (m CacheMetadata) Age(now time.Time) time.Duration {
+	if m.StoredAt.IsZero() {
+		return 0
+	}
+	return now.Sub(m.StoredAt)
+}
+
+func (m CacheMetadata) Fresh(now time.Time) bool {
+	expiresAt := m.ExpiresAt()
+	return expiresAt.IsZero() || now.Before(expiresAt)
+}
+
+func cacheGet(ctx context.Context, cache CacheBackend, key string, value interface{}, allowStale bool) (CacheMetadata, error) {
+	if err := ctx.Err(); err != nil {
+		return CacheMetadata{}, err
+	}
+	if expiringCache, ok := cache.(ExpiringCacheBackend); ok {
+		return expiringCache.GetEntry(ctx, key, value, allowStale)
+	}
+	if contextCache, ok := cache.(ContextCacheBackend); ok {
+		return CacheMetadata{}, contextCache.GetContext(ctx, key, value)
+	}
+	return CacheMetadata{}, cache.Get(key, value)
+}
+
+func cacheSet(ctx context.Context, cache CacheBackend, key string, value interface{}, metadata CacheMetadata) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if expiringCache, ok := cache.(ExpiringCacheBackend); ok {
+		return expiringCache.SetEntry(ctx, key, value, metadata)
+	}
+	if contextCache, ok := cache.(ContextCacheBackend); ok {
+		return contextCache.SetContext(ctx, key, value)
+	}
+	return cache.Set(key, value)
+}
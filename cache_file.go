@@ -0,0 +1,211 @@
+package bahn
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileCache is a persistent CacheBackend storing one JSON file per key in
+// Directory. Expired entries are kept on disk until Compact removes them
+// after they have been expired for longer than MaxStale.
+type FileCache struct {
+	Directory  string
+	DefaultTTL time.Duration
+	MaxStale   time.Duration
+	Clock      Clock
+
+	mutex sync.Mutex
+}
+
+type fileCacheEntry struct {
+	Key      string          `json:"key"`
+	StoredAt time.Time       `json:"stored_at"`
+	TTL      time.Duration   `json:"ttl"`
+	Value    json.RawMessage `json:"value"`
+}
+
+const fileCacheExtension = ".json"
+const fileCacheTempPrefix = ".tmp-"
+
+func NewFileCache(directory string) (*FileCache, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	return &FileCache{
+		Directory: directory,
+	}, nil
+}
+
+func (c *FileCache) Set(key string, value interface{}) error {
+	return c.SetEntry(context.Background(), key, value, CacheMetadata{
+		StoredAt: c.now(),
+		TTL:      c.DefaultTTL,
+	})
+}
+
+func (c *FileCache) Get(key string, value interface{}) error {
+	_, err := c.GetEntry(context.Background(), key, value, false)
+	return err
+}
+
+func (c *FileCache) SetEntry(ctx context.Context, key string, value interface{}, metadata CacheMetadata) error {
+	var err error
+
+	var data []byte
+	if data, err = json.Marshal(value); err != nil {
+		return err
+	}
+	if data, err = json.Marshal(fileCacheEntry{
+		Key:      key,
+		StoredAt: metadata.StoredAt,
+		TTL:      metadata.TTL,
+		Value:    data,
+	}); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var file *os.File
+	if file, err = ioutil.TempFile(c.Directory, fileCacheTempPrefix); err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return err
+	}
+	if err = file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	if err = os.Rename(file.Name(), c.path(key)); err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	return nil
+}
+
+func (c *FileCache) GetEntry(ctx context.Context, key string, value interface{}, allowStale bool) (CacheMetadata, error) {
+	entry, err := c.read(c.path(key))
+	if os.IsNotExist(err) {
+		return CacheMetadata{}, ErrCacheMiss
+	} else if err != nil {
+		return CacheMetadata{}, err
+	}
+	if entry.Key != key {
+		return CacheMetadata{}, ErrCacheMiss
+	}
+
+	metadata := CacheMetadata{
+		StoredAt: entry.StoredAt,
+		TTL:      entry.TTL,
+	}
+	if !allowStale && !metadata.Fresh(c.now()) {
+		return CacheMetadata{}, ErrCacheMiss
+	}
+	return metadata, json.Unmarshal(entry.Value, value)
+}
+
+func (c *FileCache) Delete(key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Compact removes entries which have been expired for longer than MaxStale,
+// entries which can no longer be decoded and leftover temporary files.
+func (c *FileCache) Compact() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	files, err := ioutil.ReadDir(c.Directory)
+	if err != nil {
+		return err
+	}
+
+	now := c.now()
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		path := filepath.Join(c.Directory, file.Name())
+		if strings.HasPrefix(file.Name(), fileCacheTempPrefix) {
+			if now.Sub(file.ModTime()) > time.Hour {
+				_ = os.Remove(path)
+			}
+			continue
+		}
+		if !strings.HasSuffix(file.Name(), fileCacheExtension) {
+			continue
+		}
+
+		entry, err := c.read(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				_ = os.Remove(path)
+			}
+			continue
+		}
+		metadata := CacheMetadata{
+			StoredAt: entry.StoredAt,
+			TTL:      entry.TTL,
+		}
+		if !metadata.Fresh(now) && now.Sub(metadata.ExpiresAt()) > c.MaxStale {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RunCompaction calls Compact every interval until ctx is done.
+func (c *FileCache) RunCompaction(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := c.Compact(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *FileCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Directory, hex.EncodeToString(hash[:])+fileCacheExtension)
+}
+
+func (c *FileCache) read(path string) (fileCacheEntry, error) {
+	var entry fileCacheEntry
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(data, &entry)
+	return entry, err
+}
+
+func (c *FileCache) now() time.Time {
+	return clockOrSystem(c.Clock).Now()
+}
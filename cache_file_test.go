@@ -0,0 +1,54 @@
+package bahn
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFileCachePersistence(t *testing.T) {
+	ctx := context.Background()
+
+	directory, err := ioutil.TempDir("", "bahn-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	cache, err := NewFileCache(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stations := []Station{{StationName: "Hamburg Hbf", EvaId: "8002549"}}
+	if err := cache.SetEntry(ctx, "station 8002549", stations, CacheMetadata{StoredAt: time.Now(), TTL: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SetEntry(ctx, "realtime_all 8002549", Timetable{}, CacheMetadata{StoredAt: time.Now().Add(-time.Hour), TTL: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := NewFileCache(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result []Station
+	if err := restarted.Get("station 8002549", &result); err != nil || len(result) != 1 || result[0].StationName != "Hamburg Hbf" {
+		t.Fatalf("expected persisted entry, got %+v %v", result, err)
+	}
+
+	var timetable Timetable
+	if err := restarted.Get("realtime_all 8002549", &timetable); err != ErrCacheMiss {
+		t.Errorf("expected expired entry to miss, got %v", err)
+	}
+	if err := restarted.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restarted.GetEntry(ctx, "realtime_all 8002549", &timetable, true); err != ErrCacheMiss {
+		t.Errorf("expected compacted entry to be removed, got %v", err)
+	}
+	if err := restarted.Get("station 8002549", &result); err != nil {
+		t.Errorf("expected fresh entry to survive compaction, got %v", err)
+	}
+}
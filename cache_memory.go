@@ -0,0 +1,196 @@
+package bahn
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// MemoryCache is a concurrency-safe in-memory CacheBackend with LRU eviction.
+// Values are stored JSON-encoded, so cached results never share memory with
+// the values handed out to callers. Expired entries are kept until evicted or
+// until they have been expired for longer than MaxStale (if set), so they can
+// still be served as stale results.
+type MemoryCache struct {
+	MaxEntries int
+	MaxBytes   int64
+	DefaultTTL time.Duration
+	MaxStale   time.Duration
+	Clock      Clock
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	bytes   int64
+	stats   MemoryCacheStats
+}
+
+type MemoryCacheStats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+	Entries     int
+	Bytes       int64
+}
+
+type memoryCacheEntry struct {
+	key      string
+	data     []byte
+	metadata CacheMetadata
+}
+
+func (e *memoryCacheEntry) size() int64 {
+	return int64(len(e.key) + len(e.data))
+}
+
+func NewMemoryCache(maxEntries int, maxBytes int64) *MemoryCache {
+	return &MemoryCache{
+		MaxEntries: maxEntries,
+		MaxBytes:   maxBytes,
+	}
+}
+
+func (c *MemoryCache) Set(key string, value interface{}) error {
+	return c.SetEntry(context.Background(), key, value, CacheMetadata{
+		StoredAt: c.now(),
+		TTL:      c.DefaultTTL,
+	})
+}
+
+func (c *MemoryCache) Get(key string, value interface{}) error {
+	_, err := c.GetEntry(context.Background(), key, value, false)
+	return err
+}
+
+func (c *MemoryCache) SetEntry(ctx context.Context, key string, value interface{}, metadata CacheMetadata) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	entry := &memoryCacheEntry{
+		key:      key,
+		data:     data,
+		metadata: metadata,
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.init()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	if c.MaxBytes > 0 && entry.size() > c.MaxBytes {
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	c.bytes += entry.size()
+
+	for c.overCapacity() {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+	return nil
+}
+
+func (c *MemoryCache) GetEntry(ctx context.Context, key string, value interface{}, allowStale bool) (CacheMetadata, error) {
+	c.mutex.Lock()
+	c.init()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		c.mutex.Unlock()
+		return CacheMetadata{}, ErrCacheMiss
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+	now := c.now()
+	if !entry.metadata.Fresh(now) {
+		if c.MaxStale > 0 && now.Sub(entry.metadata.ExpiresAt()) > c.MaxStale {
+			c.remove(element)
+			c.stats.Expirations++
+			c.stats.Misses++
+			c.mutex.Unlock()
+			return CacheMetadata{}, ErrCacheMiss
+		}
+		if !allowStale {
+			c.stats.Misses++
+			c.mutex.Unlock()
+			return CacheMetadata{}, ErrCacheMiss
+		}
+	}
+
+	c.order.MoveToFront(element)
+	c.stats.Hits++
+	data, metadata := entry.data, entry.metadata
+	c.mutex.Unlock()
+
+	return metadata, json.Unmarshal(data, value)
+}
+
+func (c *MemoryCache) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.init()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// Prune drops all entries which have expired, regardless of MaxStale.
+func (c *MemoryCache) Prune() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.init()
+
+	now := c.now()
+	for element := c.order.Back(); element != nil; {
+		previous := element.Prev()
+		if !element.Value.(*memoryCacheEntry).metadata.Fresh(now) {
+			c.remove(element)
+			c.stats.Expirations++
+		}
+		element = previous
+	}
+}
+
+func (c *MemoryCache) Stats() MemoryCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.init()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	stats.Bytes = c.bytes
+	return stats
+}
+
+func (c *MemoryCache) init() {
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+		c.order = list.New()
+	}
+}
+
+func (c *MemoryCache) overCapacity() bool {
+	if c.order.Len() == 0 {
+		return false
+	}
+	return (c.MaxEntries > 0 && c.order.Len() > c.MaxEntries) ||
+		(c.MaxBytes > 0 && c.bytes > c.MaxBytes)
+}
+
+func (c *MemoryCache) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*memoryCacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size()
+}
+
+func (c *MemoryCache) now() time.Time {
+	return clockOrSystem(c.Clock).Now()
+}
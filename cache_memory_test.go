@@ -0,0 +1,102 @@
+package bahn
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheEviction(t *testing.T) {
+	cache := NewMemoryCache(2, 0)
+
+	for _, key := range []string{"a", "b"} {
+		if err := cache.Set(key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var value string
+	if err := cache.Get("a", &value); err != nil || value != "a" {
+		t.Fatalf("expected hit for a, got %q %v", value, err)
+	}
+	if err := cache.Set("c", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Get("b", &value); err != ErrCacheMiss {
+		t.Errorf("expected least recently used entry b to be evicted, got %v", err)
+	}
+	if err := cache.Get("a", &value); err != nil {
+		t.Errorf("expected recently used entry a to be kept, got %v", err)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestMemoryCacheBytes(t *testing.T) {
+	cache := NewMemoryCache(0, 16)
+
+	if err := cache.Set("a", "0123456789"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Set("b", "0123456789"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := cache.Stats()
+	if stats.Entries != 1 || stats.Bytes > 16 || stats.Evictions != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(0, 0)
+
+	metadata := CacheMetadata{
+		StoredAt: time.Now().Add(-2 * time.Minute),
+		TTL:      time.Minute,
+	}
+	if err := cache.SetEntry(ctx, "timetable", Timetable{Station: "Hamburg Hbf"}, metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	var timetable Timetable
+	if _, err := cache.GetEntry(ctx, "timetable", &timetable, false); err != ErrCacheMiss {
+		t.Errorf("expected expired entry to miss, got %v", err)
+	}
+	stale, err := cache.GetEntry(ctx, "timetable", &timetable, true)
+	if err != nil || timetable.Station != "Hamburg Hbf" {
+		t.Fatalf("expected stale entry, got %+v %v", timetable, err)
+	}
+	if stale.Fresh(time.Now()) || !stale.StoredAt.Equal(metadata.StoredAt) {
+		t.Errorf("unexpected metadata %+v", stale)
+	}
+
+	cache.Prune()
+	if _, err := cache.GetEntry(ctx, "timetable", &timetable, true); err != ErrCacheMiss {
+		t.Errorf("expected pruned entry to miss, got %v", err)
+	}
+}
+
+func TestMemoryCacheClock(t *testing.T) {
+	now := time.Date(2024, 3, 31, 1, 30, 0, 0, Location)
+	cache := NewMemoryCache(0, 0)
+	cache.DefaultTTL = time.Hour
+	cache.Clock = FixedClock(now)
+
+	if err := cache.Set("station", []Station{{StationName: "Hamburg Hbf"}}); err != nil {
+		t.Fatal(err)
+	}
+	var stations []Station
+	if err := cache.Get("station", &stations); err != nil {
+		t.Fatalf("expected fresh entry, got %v", err)
+	}
+
+	cache.Clock = FixedClock(now.Add(time.Hour))
+	if err := cache.Get("station", &stations); err != ErrCacheMiss {
+		t.Errorf("expected expired entry to miss, got %v", err)
+	}
+}
@@ -1,8 +1,9 @@
 package bahn
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"github.com/golang/glog"
 	"golang.org/x/net/html/charset"
 	"io"
 	"io/ioutil"
@@ -19,327 +20,326 @@ type ApiClient struct {
 	HafasBaseUrl         string
 	HttpClient           *http.Client
 	Caches               []CacheBackend
+	Policies             map[Endpoint]EndpointPolicy
+	RateLimits           map[Upstream]RateLimit
+	Retry                *RetryPolicy
+	Logger               Logger
+	StrictParsing        bool
+	Clock                Clock
+
+	flights  flightGroup
+	limiters rateLimiters
 }
 
+type Endpoint string
+
+const (
+	EndpointStation        Endpoint = "station"
+	EndpointTimetable      Endpoint = "timetable"
+	EndpointRealtimeAll    Endpoint = "realtime_all"
+	EndpointRealtimeRecent Endpoint = "realtime_recent"
+	EndpointWingDefinition Endpoint = "wing_definition"
+	EndpointCoachSequence  Endpoint = "coach_sequence"
+	EndpointSuggestions    Endpoint = "suggestions"
+	EndpointHafasMessages  Endpoint = "hafas_messages"
+)
+
 const cacheTimestamp = "2006-01-02T15:04"
+
+// timetableHour is the hour a plan is requested for, it is part of both the
+// upstream url and the cache key.
+const timetableHour = "060102/15"
 const cacheTimestampDate = "2006-01-02"
 
+func (c *ApiClient) logger() Logger {
+	return loggerOrNop(c.Logger)
+}
+
+func (c *ApiClient) now() time.Time {
+	return clockOrSystem(c.Clock).Now()
+}
+
+func (c *ApiClient) parseOptions() ParseOptions {
+	return ParseOptions{
+		Logger: c.logger(),
+		Strict: c.StrictParsing,
+		Clock:  c.Clock,
+	}
+}
+
 func (c *ApiClient) Station(evaId int64) ([]Station, error) {
-	key := fmt.Sprintf("realtime_recent %d", evaId)
+	return c.StationContext(context.Background(), evaId)
+}
+
+func (c *ApiClient) StationContext(ctx context.Context, evaId int64) ([]Station, error) {
 	var result []Station
-	for _, cache := range c.Caches {
-		if err := cache.Get(key, &result); err == nil {
-			for _, targetCache := range c.Caches {
-				if targetCache == cache {
-					break
-				}
-				_ = targetCache.Set(key, result)
-			}
-			return result, err
-		}
-	}
-	var err error
-	if result, err = c.loadStation(evaId); err == nil {
-		for _, cache := range c.Caches {
-			_ = cache.Set(key, result)
-		}
-	}
+	err := c.fetch(ctx, fetchRequest{
+		endpoint: EndpointStation,
+		key:      cacheKey(EndpointStation, evaId),
+		load: func(ctx context.Context) (interface{}, error) {
+			return c.loadStation(ctx, evaId)
+		},
+	}, &result)
 	return result, err
 }
 
-func (c *ApiClient) loadStation(evaId int64) ([]Station, error) {
+func (c *ApiClient) loadStation(ctx context.Context, evaId int64) ([]Station, error) {
 	var err error
 	uri := fmt.Sprintf("%s/timetable/station/%d", c.IrisBaseUrl, evaId)
-	glog.Infof("Loading Station %d", evaId)
+	c.logger().Log(LogLevelInfo, "Loading Station", LogField{"endpoint", EndpointStation}, LogField{"eva_id", evaId})
 
 	var stations []Station
 
-	var response *http.Response
-	if response, err = c.HttpClient.Get(uri); err != nil {
+	var request *http.Request
+	if request, err = http.NewRequest(http.MethodGet, uri, nil); err != nil {
 		return stations, err
 	}
 
-	if stations, err = StationsFromReader(response.Body); err != nil {
-		return stations, err
-	}
+	err = c.do(ctx, EndpointStation, request, func(body []byte) (err error) {
+		stations, err = StationsFromBytes(body)
+		return err
+	})
+	return stations, err
+}
 
-	if err = response.Body.Close(); err != nil {
+// stationByName searches stations by name, which is used to resolve the
+// stations of paths.
+func (c *ApiClient) stationByName(ctx context.Context, name string) ([]Station, error) {
+	var result []Station
+	err := c.fetch(ctx, fetchRequest{
+		endpoint: EndpointStation,
+		key:      cacheKey(EndpointStation, "name", name),
+		load: func(ctx context.Context) (interface{}, error) {
+			return c.loadStationByName(ctx, name)
+		},
+	}, &result)
+	return result, err
+}
+
+func (c *ApiClient) loadStationByName(ctx context.Context, name string) ([]Station, error) {
+	var err error
+	uri := fmt.Sprintf("%s/timetable/station/%s", c.IrisBaseUrl, url.PathEscape(name))
+	c.logger().Log(LogLevelInfo, "Loading Station", LogField{"endpoint", EndpointStation}, LogField{"name", name})
+
+	var stations []Station
+
+	var request *http.Request
+	if request, err = http.NewRequest(http.MethodGet, uri, nil); err != nil {
 		return stations, err
 	}
 
+	err = c.do(ctx, EndpointStation, request, func(body []byte) (err error) {
+		stations, err = StationsFromBytes(body)
+		return err
+	})
 	return stations, err
 }
 
 func (c *ApiClient) Timetable(evaId int64, date time.Time) (Timetable, error) {
-	key := fmt.Sprintf("timetable %d %s", evaId, date.Format(cacheTimestamp))
+	return c.TimetableContext(context.Background(), evaId, date)
+}
 
+func (c *ApiClient) TimetableContext(ctx context.Context, evaId int64, date time.Time) (Timetable, error) {
 	var result Timetable
-	for _, cache := range c.Caches {
-		if err := cache.Get(key, &result); err == nil {
-			for _, targetCache := range c.Caches {
-				if targetCache == cache {
-					break
-				}
-				_ = targetCache.Set(key, result)
-			}
-			return result, err
-		}
-	}
-	var err error
-	if result, err = c.loadTimetable(evaId, date); err == nil {
-		for _, cache := range c.Caches {
-			_ = cache.Set(key, result)
-		}
+	err := c.fetch(ctx, fetchRequest{
+		endpoint: EndpointTimetable,
+		key:      cacheKey(EndpointTimetable, evaId, date.In(Location).Format(timetableHour)),
+		load: func(ctx context.Context) (interface{}, error) {
+			return c.loadTimetable(ctx, evaId, date)
+		},
+	}, &result)
+	// plans do not carry the eva id of their station
+	if result.EvaId == 0 && result.Station != "" {
+		result.EvaId = evaId
 	}
 	return result, err
 }
 
-func (c *ApiClient) loadTimetable(evaId int64, date time.Time) (Timetable, error) {
+func (c *ApiClient) loadTimetable(ctx context.Context, evaId int64, date time.Time) (Timetable, error) {
 	var err error
 
-	BahnFormat := "060102/15"
-	uri := fmt.Sprintf("%s/timetable/plan/%d/%s", c.IrisBaseUrl, evaId, date.Format(BahnFormat))
-	glog.Infof("Loading Timetable %d %s", evaId, date.Format(time.RFC3339))
+	uri := fmt.Sprintf("%s/timetable/plan/%d/%s", c.IrisBaseUrl, evaId, date.In(Location).Format(timetableHour))
+	c.logger().Log(LogLevelInfo, "Loading Timetable", LogField{"endpoint", EndpointTimetable}, LogField{"eva_id", evaId}, LogField{"date", date.Format(time.RFC3339)})
 
 	var timetable Timetable
 
-	var response *http.Response
-	if response, err = c.HttpClient.Get(uri); err != nil {
-		return timetable, err
-	}
-
-	if timetable, err = TimetableFromReader(response.Body); err != nil {
-		return timetable, err
-	}
-
-	if err = response.Body.Close(); err != nil {
+	var request *http.Request
+	if request, err = http.NewRequest(http.MethodGet, uri, nil); err != nil {
 		return timetable, err
 	}
 
+	err = c.do(ctx, EndpointTimetable, request, func(body []byte) (err error) {
+		timetable, err = TimetableFromBytesWithOptions(body, c.parseOptions())
+		return err
+	})
 	return timetable, err
 }
 
 func (c *ApiClient) RealtimeAll(evaId int64, date time.Time) (Timetable, error) {
-	key := fmt.Sprintf("realtime_all %d %s", evaId, date.Format(cacheTimestamp))
+	return c.RealtimeAllContext(context.Background(), evaId, date)
+}
 
+func (c *ApiClient) RealtimeAllContext(ctx context.Context, evaId int64, date time.Time) (Timetable, error) {
 	var result Timetable
-	for _, cache := range c.Caches {
-		if err := cache.Get(key, &result); err == nil {
-			for _, targetCache := range c.Caches {
-				if targetCache == cache {
-					break
-				}
-				_ = targetCache.Set(key, result)
-			}
-			return result, err
-		}
-	}
-	var err error
-	if result, err = c.loadRealtimeAll(evaId, date); err == nil {
-		for _, cache := range c.Caches {
-			_ = cache.Set(key, result)
-		}
-	}
+	err := c.fetch(ctx, fetchRequest{
+		endpoint: EndpointRealtimeAll,
+		key:      cacheKey(EndpointRealtimeAll, evaId),
+		load: func(ctx context.Context) (interface{}, error) {
+			return c.loadRealtimeAll(ctx, evaId, date)
+		},
+	}, &result)
 	return result, err
 }
 
-func (c *ApiClient) loadRealtimeAll(evaId int64, date time.Time) (Timetable, error) {
+func (c *ApiClient) loadRealtimeAll(ctx context.Context, evaId int64, date time.Time) (Timetable, error) {
 	var err error
 
 	uri := fmt.Sprintf("%s/timetable/fchg/%d", c.IrisBaseUrl, evaId)
-	glog.Infof("Loading RealtimeAll %d %s", evaId, date.Format(time.RFC3339))
+	c.logger().Log(LogLevelInfo, "Loading RealtimeAll", LogField{"endpoint", EndpointRealtimeAll}, LogField{"eva_id", evaId}, LogField{"date", date.Format(time.RFC3339)})
 
 	var timetable Timetable
 
-	var response *http.Response
-	if response, err = c.HttpClient.Get(uri); err != nil {
-		return timetable, err
-	}
-
-	if timetable, err = TimetableFromReader(response.Body); err != nil {
-		return timetable, err
-	}
-
-	if err = response.Body.Close(); err != nil {
+	var request *http.Request
+	if request, err = http.NewRequest(http.MethodGet, uri, nil); err != nil {
 		return timetable, err
 	}
 
+	err = c.do(ctx, EndpointRealtimeAll, request, func(body []byte) (err error) {
+		timetable, err = TimetableFromBytesWithOptions(body, c.parseOptions())
+		return err
+	})
 	return timetable, err
 }
 
 func (c *ApiClient) RealtimeRecent(evaId int64, date time.Time) (Timetable, error) {
-	key := fmt.Sprintf("realtime_recent %d %s", evaId, date.Format(cacheTimestamp))
+	return c.RealtimeRecentContext(context.Background(), evaId, date)
+}
 
+func (c *ApiClient) RealtimeRecentContext(ctx context.Context, evaId int64, date time.Time) (Timetable, error) {
 	var result Timetable
-	for _, cache := range c.Caches {
-		if err := cache.Get(key, &result); err == nil {
-			for _, targetCache := range c.Caches {
-				if targetCache == cache {
-					break
-				}
-				_ = targetCache.Set(key, result)
-			}
-			return result, err
-		}
-	}
-	var err error
-	if result, err = c.loadRealtimeRecent(evaId, date); err == nil {
-		for _, cache := range c.Caches {
-			_ = cache.Set(key, result)
-		}
-	}
+	err := c.fetch(ctx, fetchRequest{
+		endpoint: EndpointRealtimeRecent,
+		key:      cacheKey(EndpointRealtimeRecent, evaId),
+		load: func(ctx context.Context) (interface{}, error) {
+			return c.loadRealtimeRecent(ctx, evaId, date)
+		},
+	}, &result)
 	return result, err
 }
 
-func (c *ApiClient) loadRealtimeRecent(evaId int64, date time.Time) (Timetable, error) {
+func (c *ApiClient) loadRealtimeRecent(ctx context.Context, evaId int64, date time.Time) (Timetable, error) {
 	var err error
 
 	uri := fmt.Sprintf("%s/timetable/rchg/%d", c.IrisBaseUrl, evaId)
-	glog.Infof("Loading RealtimeRecent %d %s", evaId, date.Format(time.RFC3339))
+	c.logger().Log(LogLevelInfo, "Loading RealtimeRecent", LogField{"endpoint", EndpointRealtimeRecent}, LogField{"eva_id", evaId}, LogField{"date", date.Format(time.RFC3339)})
 
 	var timetable Timetable
 
-	var response *http.Response
-	if response, err = c.HttpClient.Get(uri); err != nil {
-		return timetable, err
-	}
-
-	if timetable, err = TimetableFromReader(response.Body); err != nil {
-		return timetable, err
-	}
-
-	if err = response.Body.Close(); err != nil {
+	var request *http.Request
+	if request, err = http.NewRequest(http.MethodGet, uri, nil); err != nil {
 		return timetable, err
 	}
 
+	err = c.do(ctx, EndpointRealtimeRecent, request, func(body []byte) (err error) {
+		timetable, err = TimetableFromBytesWithOptions(body, c.parseOptions())
+		return err
+	})
 	return timetable, err
 }
 
 func (c *ApiClient) WingDefinition(parent string, wing string) (WingDefinition, error) {
-	key := fmt.Sprintf("wing_definition %s %s", parent, wing)
+	return c.WingDefinitionContext(context.Background(), parent, wing)
+}
 
+func (c *ApiClient) WingDefinitionContext(ctx context.Context, parent string, wing string) (WingDefinition, error) {
 	var result WingDefinition
-	for _, cache := range c.Caches {
-		if err := cache.Get(key, &result); err == nil {
-			for _, targetCache := range c.Caches {
-				if targetCache == cache {
-					break
-				}
-				_ = targetCache.Set(key, result)
-			}
-			return result, err
-		}
-	}
-	var err error
-	if result, err = c.loadWingDefinition(parent, wing); err == nil {
-		for _, cache := range c.Caches {
-			_ = cache.Set(key, result)
-		}
-	}
+	err := c.fetch(ctx, fetchRequest{
+		endpoint: EndpointWingDefinition,
+		key:      cacheKey(EndpointWingDefinition, parent, wing),
+		load: func(ctx context.Context) (interface{}, error) {
+			return c.loadWingDefinition(ctx, parent, wing)
+		},
+	}, &result)
 	return result, err
 }
 
-func (c *ApiClient) loadWingDefinition(parent string, wing string) (WingDefinition, error) {
+func (c *ApiClient) loadWingDefinition(ctx context.Context, parent string, wing string) (WingDefinition, error) {
 	var err error
 
 	uri := fmt.Sprintf("%s/timetable/wingdef/%s/%s", c.IrisBaseUrl, parent, wing)
-	glog.Infof("Loading WingDefinition %s %s", parent, wing)
+	c.logger().Log(LogLevelInfo, "Loading WingDefinition", LogField{"endpoint", EndpointWingDefinition}, LogField{"parent", parent}, LogField{"wing", wing})
 
 	var wingDefinition WingDefinition
 
-	var response *http.Response
-	if response, err = c.HttpClient.Get(uri); err != nil {
-		return wingDefinition, err
-	}
-
-	if wingDefinition, err = WingDefinitionFromReader(response.Body); err != nil {
-		return wingDefinition, err
-	}
-
-	if err = response.Body.Close(); err != nil {
+	var request *http.Request
+	if request, err = http.NewRequest(http.MethodGet, uri, nil); err != nil {
 		return wingDefinition, err
 	}
 
+	err = c.do(ctx, EndpointWingDefinition, request, func(body []byte) (err error) {
+		wingDefinition, err = WingDefinitionFromBytes(body)
+		return err
+	})
 	return wingDefinition, err
 }
 
 func (c *ApiClient) CoachSequence(line string, date time.Time) (CoachSequence, error) {
-	key := fmt.Sprintf("coach_sequence %s %s", line, date.Format(cacheTimestamp))
+	return c.CoachSequenceContext(context.Background(), line, date)
+}
 
+func (c *ApiClient) CoachSequenceContext(ctx context.Context, line string, date time.Time) (CoachSequence, error) {
 	var result CoachSequence
-	for _, cache := range c.Caches {
-		if err := cache.Get(key, &result); err == nil {
-			for _, targetCache := range c.Caches {
-				if targetCache == cache {
-					break
-				}
-				_ = targetCache.Set(key, result)
-			}
-			return result, err
-		}
-	}
-	var err error
-	if result, err = c.loadCoachSequence(line, date); err == nil {
-		for _, cache := range c.Caches {
-			_ = cache.Set(key, result)
-		}
-	}
+	err := c.fetch(ctx, fetchRequest{
+		endpoint: EndpointCoachSequence,
+		key:      cacheKey(EndpointCoachSequence, line, date.In(Location).Format(cacheTimestamp)),
+		load: func(ctx context.Context) (interface{}, error) {
+			return c.loadCoachSequence(ctx, line, date)
+		},
+	}, &result)
 	return result, err
 }
 
-func (c *ApiClient) loadCoachSequence(line string, date time.Time) (CoachSequence, error) {
+func (c *ApiClient) loadCoachSequence(ctx context.Context, line string, date time.Time) (CoachSequence, error) {
 	var err error
 
-	uri := fmt.Sprintf("%s/%s/%s", c.CoachSequenceBaseUrl, line, date.Format(TimeLayoutMediumShort))
-	glog.Infof("Loading CoachSequence %s %s", line, date.Format(time.RFC3339))
+	uri := fmt.Sprintf("%s/%s/%s", c.CoachSequenceBaseUrl, line, date.In(Location).Format(TimeLayoutMediumShort))
+	c.logger().Log(LogLevelInfo, "Loading CoachSequence", LogField{"endpoint", EndpointCoachSequence}, LogField{"line", line}, LogField{"date", date.Format(time.RFC3339)})
 
 	var coachSequence CoachSequence
 
-	var response *http.Response
-	if response, err = c.HttpClient.Get(uri); err != nil {
-		return coachSequence, err
-	}
-
-	if coachSequence, err = CoachSequenceFromReader(response.Body); err != nil {
-		return coachSequence, err
-	}
-
-	if err = response.Body.Close(); err != nil {
+	var request *http.Request
+	if request, err = http.NewRequest(http.MethodGet, uri, nil); err != nil {
 		return coachSequence, err
 	}
 
+	err = c.do(ctx, EndpointCoachSequence, request, func(body []byte) (err error) {
+		coachSequence, err = CoachSequenceFromBytes(body)
+		return err
+	})
 	return coachSequence, err
 }
 
 func (c *ApiClient) Suggestions(line string, date time.Time) ([]Suggestion, error) {
-	key := fmt.Sprintf("suggestions %s %s", line, date.Format(cacheTimestampDate))
+	return c.SuggestionsContext(context.Background(), line, date)
+}
 
+func (c *ApiClient) SuggestionsContext(ctx context.Context, line string, date time.Time) ([]Suggestion, error) {
 	var result []Suggestion
-	for _, cache := range c.Caches {
-		if err := cache.Get(key, &result); err == nil {
-			for _, targetCache := range c.Caches {
-				if targetCache == cache {
-					break
-				}
-				_ = targetCache.Set(key, result)
-			}
-			return result, err
-		}
-	}
-	var err error
-	if result, err = c.loadSuggestions(line, date); err == nil {
-		for _, cache := range c.Caches {
-			_ = cache.Set(key, result)
-		}
-	}
+	err := c.fetch(ctx, fetchRequest{
+		endpoint: EndpointSuggestions,
+		key:      cacheKey(EndpointSuggestions, line, date.In(Location).Format(cacheTimestampDate)),
+		load: func(ctx context.Context) (interface{}, error) {
+			return c.loadSuggestions(ctx, line, date)
+		},
+	}, &result)
 	return result, err
 }
 
-func (c *ApiClient) loadSuggestions(line string, date time.Time) ([]Suggestion, error) {
+func (c *ApiClient) loadSuggestions(ctx context.Context, line string, date time.Time) ([]Suggestion, error) {
 	var err error
 
 	uri := fmt.Sprintf("%s/trainsearch.exe/dn", c.HafasBaseUrl)
-	glog.Infof("Loading Suggestions %s %s", line, date.Format(time.RFC3339))
+	c.logger().Log(LogLevelInfo, "Loading Suggestions", LogField{"endpoint", EndpointSuggestions}, LogField{"line", line}, LogField{"date", date.Format(time.RFC3339)})
 
 	var suggestions []Suggestion
 
@@ -347,84 +347,161 @@ func (c *ApiClient) loadSuggestions(line string, date time.Time) ([]Suggestion,
 	body := url.Values{
 		"maxResults": []string{"50"},
 		"trainname":  []string{line},
-		"date":       []string{date.Format(DateFormat)},
+		"date":       []string{date.In(Location).Format(DateFormat)},
 		"L":          []string{"vs_json.vs_hap"},
 	}
 
-	var response *http.Response
-	if response, err = c.HttpClient.PostForm(uri, body); err != nil {
-		return suggestions, err
-	}
-
-	var utf8reader io.Reader
-	if utf8reader, err = charset.NewReaderLabel("ISO 8859-1", response.Body); err != nil {
-		return suggestions, nil
-	}
-
-	var content []byte
-	if content, err = ioutil.ReadAll(utf8reader); err != nil {
+	var request *http.Request
+	if request, err = http.NewRequest(http.MethodPost, uri, strings.NewReader(body.Encode())); err != nil {
 		return suggestions, err
 	}
-	strippedContent := string(content)
-	strippedContent = strings.TrimPrefix(strippedContent, "TSLs.sls = ")
-	strippedContent = strings.TrimSuffix(strippedContent, ";")
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	if suggestions, err = SuggestionsFromBytes([]byte(strippedContent)); err != nil {
-		return suggestions, err
-	}
+	err = c.do(ctx, EndpointSuggestions, request, func(body []byte) (err error) {
+		var utf8reader io.Reader
+		if utf8reader, err = charset.NewReaderLabel("ISO 8859-1", bytes.NewReader(body)); err != nil {
+			return err
+		}
 
-	if err = response.Body.Close(); err != nil {
-		return suggestions, err
-	}
+		var content []byte
+		if content, err = ioutil.ReadAll(utf8reader); err != nil {
+			return err
+		}
+		strippedContent := string(content)
+		strippedContent = strings.TrimPrefix(strippedContent, "TSLs.sls = ")
+		strippedContent = strings.TrimSuffix(strippedContent, ";")
 
+		suggestions, err = SuggestionsFromBytesWithOptions([]byte(strippedContent), c.parseOptions())
+		return err
+	})
 	return suggestions, err
 }
 
 func (c *ApiClient) HafasMessages(trainlink string) ([]HafasMessage, error) {
-	key := fmt.Sprintf("hafas_messages %s", trainlink)
+	return c.HafasMessagesContext(context.Background(), trainlink)
+}
 
+func (c *ApiClient) HafasMessagesContext(ctx context.Context, trainlink string) ([]HafasMessage, error) {
 	var result []HafasMessage
-	for _, cache := range c.Caches {
-		if err := cache.Get(key, &result); err == nil {
-			for _, targetCache := range c.Caches {
-				if targetCache == cache {
-					break
-				}
-				_ = targetCache.Set(key, result)
-			}
-			return result, err
-		}
-	}
-	var err error
-	if result, err = c.loadHafasMessages(trainlink); err == nil {
-		for _, cache := range c.Caches {
-			_ = cache.Set(key, result)
-		}
-	}
+	err := c.fetch(ctx, fetchRequest{
+		endpoint: EndpointHafasMessages,
+		key:      cacheKey(EndpointHafasMessages, trainlink),
+		load: func(ctx context.Context) (interface{}, error) {
+			return c.loadHafasMessages(ctx, trainlink)
+		},
+	}, &result)
 	return result, err
 }
 
-func (c *ApiClient) loadHafasMessages(trainlink string) ([]HafasMessage, error) {
+func (c *ApiClient) loadHafasMessages(ctx context.Context, trainlink string) ([]HafasMessage, error) {
 	var err error
 
 	uri := fmt.Sprintf("%s/traininfo.exe/dn/%s?rt=1&ajax=1", c.HafasBaseUrl, trainlink)
-	glog.Infof("Loading HafasMessages %s", trainlink)
+	c.logger().Log(LogLevelInfo, "Loading HafasMessages", LogField{"endpoint", EndpointHafasMessages}, LogField{"trainlink", trainlink})
 
 	var messages []HafasMessage
-	request, err := http.NewRequest("GET", uri, nil)
 
-	var response *http.Response
-	if response, err = c.HttpClient.Do(request); err != nil {
+	var request *http.Request
+	if request, err = http.NewRequest(http.MethodGet, uri, nil); err != nil {
 		return messages, err
 	}
 
-	if messages, err = HafasMessagesFromReader(response.Body); err != nil {
-		return messages, err
+	err = c.do(ctx, EndpointHafasMessages, request, func(body []byte) (err error) {
+		messages, err = HafasMessagesFromBytes(body)
+		return err
+	})
+	return messages, err
+}
+
+func (c *ApiClient) do(ctx context.Context, endpoint Endpoint, request *http.Request, decode func(body []byte) error) error {
+	maxAttempts := 1
+	if c.Retry != nil && request.Method == http.MethodGet && c.Retry.MaxAttempts > 1 {
+		maxAttempts = c.Retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := c.doAttempt(ctx, endpoint, request, decode)
+		fields := []LogField{
+			{"endpoint", endpoint},
+			{"url", request.URL.String()},
+			{"attempt", attempt},
+			{"duration", time.Since(start)},
+		}
+		if err == nil {
+			c.logger().Log(LogLevelDebug, "Upstream request succeeded", fields...)
+			return nil
+		}
+		c.logger().Log(LogLevelWarn, "Upstream request failed", append(fields, LogField{"error", err})...)
+
+		upstreamError, ok := err.(*UpstreamError)
+		if ok {
+			upstreamError.Attempts = attempt
+		}
+		if attempt >= maxAttempts || !c.Retry.retryable(err) {
+			return err
+		}
+
+		var retryAfter time.Duration
+		if ok {
+			retryAfter = upstreamError.RetryAfter
+		}
+		if sleepErr := sleepContext(ctx, c.Retry.backoff(attempt, retryAfter)); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+func (c *ApiClient) doAttempt(ctx context.Context, endpoint Endpoint, request *http.Request, decode func(body []byte) error) error {
+	var err error
+	uri := request.URL.String()
+
+	var release func()
+	if release, err = c.limiters.get(endpoint.Upstream(), c.RateLimits).acquire(ctx); err != nil {
+		upstreamError := &UpstreamError{Endpoint: endpoint, Url: uri, Err: err}
+		if err == ErrRateLimitExceeded {
+			upstreamError.Kind, upstreamError.Err = ErrRateLimitExceeded, nil
+		}
+		return upstreamError
+	}
+	defer release()
+
+	var response *http.Response
+	if response, err = c.HttpClient.Do(request.WithContext(ctx)); err != nil {
+		upstreamError := &UpstreamError{Endpoint: endpoint, Url: uri, Err: err}
+		if ctx.Err() == nil {
+			upstreamError.Kind = ErrUpstreamUnavailable
+		}
+		return upstreamError
+	}
+
+	var body []byte
+	if body, err = ioutil.ReadAll(response.Body); err != nil {
+		_ = response.Body.Close()
+		return &UpstreamError{Endpoint: endpoint, Url: uri, StatusCode: response.StatusCode, Kind: ErrUpstreamUnavailable, Err: err}
 	}
 
 	if err = response.Body.Close(); err != nil {
-		return messages, err
+		return &UpstreamError{Endpoint: endpoint, Url: uri, StatusCode: response.StatusCode, Kind: ErrUpstreamUnavailable, Err: err}
 	}
 
-	return messages, err
+	if kind := statusErrorKind(response.StatusCode); kind != nil {
+		return &UpstreamError{
+			Endpoint:   endpoint,
+			Url:        uri,
+			StatusCode: response.StatusCode,
+			RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"), c.now()),
+			Kind:       kind,
+		}
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return &UpstreamError{Endpoint: endpoint, Url: uri, StatusCode: response.StatusCode, Kind: ErrEmptyResponse}
+	}
+
+	if err = decode(body); err != nil {
+		return &UpstreamError{Endpoint: endpoint, Url: uri, StatusCode: response.StatusCode, Kind: ErrMalformedPayload, Err: err}
+	}
+
+	return nil
 }
@@ -0,0 +1,354 @@
+package bahn
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(handler http.HandlerFunc) (*ApiClient, func()) {
+	server := httptest.NewServer(handler)
+	client := &ApiClient{
+		IrisBaseUrl:          server.URL,
+		CoachSequenceBaseUrl: server.URL,
+		HafasBaseUrl:         server.URL,
+		HttpClient:           server.Client(),
+	}
+	return client, server.Close
+}
+
+func TestUpstreamErrors(t *testing.T) {
+	cases := []struct {
+		status     int
+		body       string
+		retryAfter string
+		kind       error
+	}{
+		{http.StatusNotFound, "<html>Not Found</html>", "", ErrNotFound},
+		{http.StatusTooManyRequests, "", "120", ErrRateLimited},
+		{http.StatusServiceUnavailable, "<html>Unavailable</html>", "", ErrUpstreamUnavailable},
+		{http.StatusForbidden, "", "", ErrUnexpectedStatus},
+		{http.StatusOK, "", "", ErrEmptyResponse},
+		{http.StatusOK, "<html>Maintenance</html>", "", ErrMalformedPayload},
+	}
+
+	for _, testCase := range cases {
+		client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+			if testCase.retryAfter != "" {
+				writer.Header().Set("Retry-After", testCase.retryAfter)
+			}
+			writer.WriteHeader(testCase.status)
+			_, _ = writer.Write([]byte(testCase.body))
+		})
+
+		_, err := client.Timetable(8002549, time.Date(2019, 4, 24, 15, 0, 0, 0, time.UTC))
+		closeServer()
+
+		if !errors.Is(err, testCase.kind) {
+			t.Errorf("status %d: expected %v, got %v", testCase.status, testCase.kind, err)
+			continue
+		}
+		var upstreamError *UpstreamError
+		if !errors.As(err, &upstreamError) {
+			t.Errorf("status %d: expected UpstreamError, got %T", testCase.status, err)
+			continue
+		}
+		if upstreamError.Endpoint != EndpointTimetable || upstreamError.StatusCode != testCase.status {
+			t.Errorf("status %d: unexpected error details %+v", testCase.status, upstreamError)
+		}
+		if testCase.retryAfter != "" && upstreamError.RetryAfter != 120*time.Second {
+			t.Errorf("status %d: expected retry after 2m, got %s", testCase.status, upstreamError.RetryAfter)
+		}
+	}
+}
+
+func TestCoalescing(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		_, _ = writer.Write(timetableData)
+	})
+	defer closeServer()
+
+	const callers = 10
+	date := time.Date(2019, 4, 24, 15, 0, 0, 0, time.UTC)
+	key := cacheKey(EndpointTimetable, int64(8002549), date.In(Location).Format(timetableHour))
+
+	// callers asking for different minutes of the same hour share the plan
+	var group sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		group.Add(1)
+		go func(date time.Time) {
+			defer group.Done()
+			_, err := client.Timetable(8002549, date)
+			errs <- err
+		}(date.Add(time.Duration(i*5) * time.Minute))
+	}
+
+	for {
+		client.flights.mutex.Lock()
+		current := client.flights.flights[key]
+		joined := current != nil && current.waiters == callers
+		client.flights.mutex.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	group.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected a single upstream request, got %d", requests)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var requests int32
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&requests, 1) != 3 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = writer.Write(realtimeData)
+	})
+	defer closeServer()
+
+	client.Retry = &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Multiplier:     2,
+	}
+	date := time.Date(2019, 4, 24, 15, 0, 0, 0, time.UTC)
+	if _, err := client.RealtimeAll(8002549, date); err != nil {
+		t.Fatalf("expected success on third attempt, got %v", err)
+	}
+
+	client.Retry.MaxAttempts = 2
+	_, err := client.RealtimeRecent(8002549, date)
+	var upstreamError *UpstreamError
+	if !errors.As(err, &upstreamError) || upstreamError.Attempts != 2 {
+		t.Fatalf("expected error after 2 attempts, got %v", err)
+	}
+	if requests != 5 {
+		t.Errorf("expected 5 upstream requests, got %d", requests)
+	}
+}
+
+func TestStaleIfError(t *testing.T) {
+	var available int32 = 1
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.LoadInt32(&available) == 0 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = writer.Write(timetableData)
+	})
+	defer closeServer()
+
+	client.Caches = []CacheBackend{NewMemoryCache(0, 0)}
+	client.Policies = map[Endpoint]EndpointPolicy{
+		EndpointTimetable: {TTL: time.Nanosecond, StaleIfError: time.Hour},
+	}
+
+	date := time.Date(2019, 4, 24, 15, 0, 0, 0, time.UTC)
+	fresh, err := client.Timetable(8002549, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&available, 0)
+	stale, err := client.Timetable(8002549, date)
+	var staleError *StaleError
+	if !errors.As(err, &staleError) || !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("expected stale result, got %v", err)
+	}
+	if stale.Station != fresh.Station || len(stale.Stops) != len(fresh.Stops) {
+		t.Errorf("expected cached timetable, got %+v", stale)
+	}
+
+	client.Policies[EndpointTimetable] = EndpointPolicy{TTL: time.Nanosecond}
+	if _, err := client.Timetable(8002549, date); errors.As(err, &staleError) || !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("expected upstream error without stale fallback, got %v", err)
+	}
+}
+
+type contextCacheKey struct{}
+
+// contextCache records the context of each lookup and never hits.
+type contextCache struct {
+	mutex    sync.Mutex
+	contexts []context.Context
+}
+
+func (c *contextCache) Set(key string, value interface{}) error {
+	return nil
+}
+
+func (c *contextCache) Get(key string, value interface{}) error {
+	return ErrCacheMiss
+}
+
+func (c *contextCache) SetContext(ctx context.Context, key string, value interface{}) error {
+	return nil
+}
+
+func (c *contextCache) GetContext(ctx context.Context, key string, value interface{}) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.contexts = append(c.contexts, ctx)
+	return ErrCacheMiss
+}
+
+func TestContextCancellation(t *testing.T) {
+	started := make(chan struct{}, 1)
+	aborted := make(chan struct{}, 1)
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		started <- struct{}{}
+		<-request.Context().Done()
+		aborted <- struct{}{}
+	})
+	defer closeServer()
+
+	cache := &contextCache{}
+	client.Caches = []CacheBackend{cache}
+	date := time.Date(2019, 4, 24, 15, 0, 0, 0, time.UTC)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextCacheKey{}, "caller"))
+	go func() {
+		<-started
+		cancel()
+	}()
+	if _, err := client.TimetableContext(ctx, 8002549, date); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Fatal("expected the upstream request to be aborted")
+	}
+
+	cache.mutex.Lock()
+	if len(cache.contexts) != 1 || cache.contexts[0].Value(contextCacheKey{}) != "caller" {
+		t.Errorf("expected the cache lookup to receive the caller's context, got %v", cache.contexts)
+	}
+	cache.mutex.Unlock()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.RealtimeAllContext(ctx, 8002549, date); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Fatal("expected the upstream request to be aborted")
+	}
+}
+
+// legacyCache only implements the plain CacheBackend interface.
+type legacyCache struct {
+	values map[string]interface{}
+}
+
+func (c *legacyCache) Set(key string, value interface{}) error {
+	c.values[key] = value
+	return nil
+}
+
+func (c *legacyCache) Get(key string, value interface{}) error {
+	stored, ok := c.values[key]
+	if !ok {
+		return ErrCacheMiss
+	}
+	reflect.ValueOf(value).Elem().Set(reflect.ValueOf(stored))
+	return nil
+}
+
+func TestCacheBackfill(t *testing.T) {
+	var requests int32
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = writer.Write(realtimeData)
+	})
+	defer closeServer()
+
+	now := time.Date(2019, 4, 24, 15, 0, 0, 0, Location)
+	legacy := &legacyCache{values: make(map[string]interface{})}
+	memory := NewMemoryCache(0, 0)
+	memory.Clock = FixedClock(now)
+	client.Clock = FixedClock(now)
+
+	client.Caches = []CacheBackend{legacy}
+	if _, err := client.RealtimeRecent(8002549, now); err != nil {
+		t.Fatal(err)
+	}
+
+	client.Caches = []CacheBackend{memory, legacy}
+	if _, err := client.RealtimeRecent(8002549, now); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("expected the legacy cache to be used, got %d upstream requests", requests)
+	}
+
+	key := cacheKey(EndpointRealtimeRecent, int64(8002549))
+	var timetable Timetable
+	metadata, err := memory.GetEntry(context.Background(), key, &timetable, true)
+	if err != nil {
+		t.Fatalf("expected backfilled entry, got %v", err)
+	}
+	if !metadata.StoredAt.Equal(now) || metadata.TTL != DefaultEndpointPolicy(EndpointRealtimeRecent).TTL {
+		t.Errorf("unexpected backfill metadata %+v", metadata)
+	}
+
+	memory.Clock = FixedClock(now.Add(24 * time.Hour))
+	if _, err := memory.GetEntry(context.Background(), key, &timetable, false); err != ErrCacheMiss {
+		t.Errorf("expected backfilled entry to expire, got %v", err)
+	}
+}
+
+func TestCacheClientClock(t *testing.T) {
+	var requests int32
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = writer.Write(timetableData)
+	})
+	defer closeServer()
+
+	now := time.Date(2019, 4, 24, 15, 0, 0, 0, Location)
+	client.Clock = FixedClock(now)
+	client.Caches = []CacheBackend{NewMemoryCache(0, 0)}
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Timetable(8002549, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected a single upstream request, got %d", requests)
+	}
+
+	client.Clock = FixedClock(now.Add(DefaultEndpointPolicy(EndpointTimetable).TTL))
+	if _, err := client.Timetable(8002549, now); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("expected expired entry to be loaded again, got %d upstream requests", requests)
+	}
+}
@@ -0,0 +1,29 @@
+package bahn
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+var SystemClock Clock = systemClock{}
+
+// FixedClock always returns the same time, e.g. to replay fixtures.
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
@@ -0,0 +1,72 @@
+package bahn
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup deduplicates concurrent loads for the same key. The shared load
+// runs with its own context, which is cancelled once every caller waiting for
+// it has given up, so one caller cancelling does not fail the others.
+type flightGroup struct {
+	mutex   sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	value   interface{}
+	err     error
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, load func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	g.mutex.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	current, ok := g.flights[key]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(context.Background())
+		current = &flight{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		g.flights[key] = current
+		go g.run(flightCtx, key, current, load)
+	}
+	current.waiters++
+	g.mutex.Unlock()
+
+	select {
+	case <-current.done:
+		return current.value, current.err
+	case <-ctx.Done():
+		g.mutex.Lock()
+		current.waiters--
+		if current.waiters == 0 {
+			current.cancel()
+			g.forget(key, current)
+		}
+		g.mutex.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, current *flight, load func(ctx context.Context) (interface{}, error)) {
+	current.value, current.err = load(ctx)
+
+	g.mutex.Lock()
+	g.forget(key, current)
+	g.mutex.Unlock()
+
+	current.cancel()
+	close(current.done)
+}
+
+func (g *flightGroup) forget(key string, current *flight) {
+	if g.flights[key] == current {
+		delete(g.flights, key)
+	}
+}
@@ -0,0 +1,139 @@
+package bahn
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrMalformedPayload    = errors.New("malformed payload")
+	ErrEmptyResponse       = errors.New("empty response")
+	ErrUnexpectedStatus    = errors.New("unexpected status")
+	ErrRateLimitExceeded   = errors.New("client rate limit exceeded")
+	ErrInvalidQuery        = errors.New("invalid query")
+	ErrInvalidStopId       = errors.New("invalid stop id")
+)
+
+// UpstreamError describes a failed request against one of the upstream APIs.
+// Kind is one of the Err* sentinels above and can be matched with errors.Is,
+// Err is the underlying cause, if any.
+type UpstreamError struct {
+	Endpoint   Endpoint
+	Url        string
+	StatusCode int
+	RetryAfter time.Duration
+	Attempts   int
+	Kind       error
+	Err        error
+}
+
+func (e *UpstreamError) Error() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s %s", e.Endpoint, e.Url))
+	if e.StatusCode != 0 {
+		builder.WriteString(fmt.Sprintf(": status %d", e.StatusCode))
+	}
+	if e.Kind != nil {
+		builder.WriteString(": ")
+		builder.WriteString(e.Kind.Error())
+	}
+	if e.Err != nil {
+		builder.WriteString(": ")
+		builder.WriteString(e.Err.Error())
+	}
+	if e.Attempts > 1 {
+		builder.WriteString(fmt.Sprintf(" (after %d attempts)", e.Attempts))
+	}
+	return builder.String()
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UpstreamError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// StaleError is returned together with a cached result if the upstream
+// request failed and the endpoint policy allows serving stale results.
+type StaleError struct {
+	StoredAt time.Time
+	Age      time.Duration
+	Err      error
+}
+
+func (e *StaleError) Error() string {
+	return fmt.Sprintf("serving result cached %s ago: %s", e.Age.Round(time.Second), e.Err.Error())
+}
+
+func (e *StaleError) Unwrap() error {
+	return e.Err
+}
+
+// staleResults collects the stale errors of several fetches combined into one
+// result, so the data can still be returned together with the oldest one.
+type staleResults struct {
+	oldest *StaleError
+}
+
+// add records err if it is a *StaleError and returns nil, other errors are
+// returned unchanged.
+func (s *staleResults) add(err error) error {
+	var staleError *StaleError
+	if !errors.As(err, &staleError) {
+		return err
+	}
+	if s.oldest == nil || staleError.StoredAt.Before(s.oldest.StoredAt) {
+		s.oldest = staleError
+	}
+	return nil
+}
+
+func (s *staleResults) err() error {
+	if s.oldest == nil {
+		return nil
+	}
+	return s.oldest
+}
+
+func statusErrorKind(statusCode int) error {
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return nil
+	case statusCode == http.StatusNotFound || statusCode == http.StatusGone:
+		return ErrNotFound
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode >= 500:
+		return ErrUpstreamUnavailable
+	default:
+		return ErrUnexpectedStatus
+	}
+}
+
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
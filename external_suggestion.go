@@ -7,29 +7,39 @@ import (
 )
 
 func SuggestionsFromReader(source io.Reader) ([]Suggestion, error) {
+	return SuggestionsFromReaderWithOptions(source, ParseOptions{})
+}
+
+func SuggestionsFromBytes(source []byte) ([]Suggestion, error) {
+	return SuggestionsFromBytesWithOptions(source, ParseOptions{})
+}
+
+// SuggestionsFromReaderWithOptions uses options.Clock to date times for which
+// HAFAS omits the date.
+func SuggestionsFromReaderWithOptions(source io.Reader, options ParseOptions) ([]Suggestion, error) {
 	var raw rawSuggestions
 	if err := json.NewDecoder(source).Decode(&raw); err != nil {
 		return make([]Suggestion, 0), err
 	}
-	return parseSuggestions(raw), nil
+	return parseSuggestions(raw, clockOrSystem(options.Clock).Now()), nil
 }
 
-func SuggestionsFromBytes(source []byte) ([]Suggestion, error) {
+func SuggestionsFromBytesWithOptions(source []byte, options ParseOptions) ([]Suggestion, error) {
 	var raw rawSuggestions
 	if err := json.Unmarshal(source, &raw); err != nil {
 		return make([]Suggestion, 0), err
 	}
-	return parseSuggestions(raw), nil
+	return parseSuggestions(raw, clockOrSystem(options.Clock).Now()), nil
 }
 
 type rawSuggestions struct {
 	Suggestions []rawSuggestion
 }
 
-func parseSuggestions(data rawSuggestions) []Suggestion {
+func parseSuggestions(data rawSuggestions, now time.Time) []Suggestion {
 	result := make([]Suggestion, len(data.Suggestions))
 	for i, element := range data.Suggestions {
-		result[i] = parseSuggestion(element)
+		result[i] = parseSuggestion(element, now)
 	}
 	return result
 }
@@ -51,31 +61,31 @@ type rawSuggestion struct {
 	ArrivalDate      string `json:"arrDate"`
 }
 
-func parseTime(dateStr string, timeStr string) *time.Time {
+func parseTime(dateStr string, timeStr string, now time.Time) *time.Time {
 	DateFormat := "02.01.2006"
 	DateTimeFormat := "02.01.2006 15:04"
 	if dateStr == "" {
-		dateStr = time.Now().Format(DateFormat)
+		dateStr = now.In(Location).Format(DateFormat)
 	}
 
-	dateTime, err := time.Parse(DateTimeFormat, dateStr+" "+timeStr)
+	dateTime, err := parseLocal(DateTimeFormat, dateStr+" "+timeStr)
 	if err != nil {
 		return nil
 	}
 	return &dateTime
 }
 
-func parseSuggestion(data rawSuggestion) Suggestion {
+func parseSuggestion(data rawSuggestion, now time.Time) Suggestion {
 	return Suggestion{
 		Value:            data.Value,
 		Cycle:            data.Cycle,
 		Pool:             data.Pool,
 		Id:               data.Id,
 		TrainLink:        data.TrainLink,
-		PublishedTime:    parseTime(data.PublishedDate, data.PublishedTime),
+		PublishedTime:    parseTime(data.PublishedDate, data.PublishedTime, now),
 		DepartureStation: data.DepartureStation,
-		DepartureTime:    parseTime(data.DepartureDate, data.DepartureTime),
+		DepartureTime:    parseTime(data.DepartureDate, data.DepartureTime, now),
 		ArrivalStation:   data.ArrivalStation,
-		ArrivalTime:      parseTime(data.ArrivalDate, data.ArrivalTime),
+		ArrivalTime:      parseTime(data.ArrivalDate, data.ArrivalTime, now),
 	}
 }
@@ -0,0 +1,28 @@
+package bahn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuggestionsClock(t *testing.T) {
+	source := []byte(`{"suggestions":[{"value":"ICE 608","depTime":"23:45","arrDate":"01.01.2025","arrTime":"00:30"}]}`)
+	now := time.Date(2024, 12, 31, 22, 0, 0, 0, time.UTC)
+
+	suggestions, err := SuggestionsFromBytesWithOptions(source, ParseOptions{Clock: FixedClock(now)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 {
+		t.Fatalf("expected one suggestion, got %d", len(suggestions))
+	}
+
+	departure := time.Date(2024, 12, 31, 23, 45, 0, 0, Location)
+	if suggestions[0].DepartureTime == nil || !suggestions[0].DepartureTime.Equal(departure) {
+		t.Errorf("expected departure %s, got %v", departure, suggestions[0].DepartureTime)
+	}
+	arrival := time.Date(2025, 1, 1, 0, 30, 0, 0, Location)
+	if suggestions[0].ArrivalTime == nil || !suggestions[0].ArrivalTime.Equal(arrival) {
+		t.Errorf("expected arrival %s, got %v", arrival, suggestions[0].ArrivalTime)
+	}
+}
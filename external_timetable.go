@@ -6,20 +6,107 @@ import (
 	"io"
 )
 
+// ParseOptions configures how unknown codes are handled. They are always
+// reported as ParseWarning to the Logger, with Strict set they additionally
+// fail parsing with an *UnknownCodeError.
+type ParseOptions struct {
+	Logger Logger
+	Strict bool
+	Clock  Clock
+}
+
+type ParseWarning struct {
+	Path  string `json:"path,omitempty" yaml:"path,omitempty"`
+	Type  string `json:"type,omitempty" yaml:"type,omitempty"`
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+func (w ParseWarning) String() string {
+	return fmt.Sprintf("%s: unknown %s '%s'", w.Path, w.Type, w.Value)
+}
+
+type UnknownCodeError struct {
+	Warnings []ParseWarning
+}
+
+func (e *UnknownCodeError) Error() string {
+	if len(e.Warnings) == 1 {
+		return e.Warnings[0].String()
+	}
+	return fmt.Sprintf("%s (and %d more unknown codes)", e.Warnings[0].String(), len(e.Warnings)-1)
+}
+
 func TimetableFromReader(source io.Reader) (Timetable, error) {
+	return TimetableFromReaderWithOptions(source, ParseOptions{})
+}
+
+func TimetableFromBytes(source []byte) (Timetable, error) {
+	return TimetableFromBytesWithOptions(source, ParseOptions{})
+}
+
+func TimetableFromReaderWithOptions(source io.Reader, options ParseOptions) (Timetable, error) {
+	timetable, _, err := TimetableFromReaderWithWarnings(source, options)
+	return timetable, err
+}
+
+func TimetableFromBytesWithOptions(source []byte, options ParseOptions) (Timetable, error) {
+	timetable, _, err := TimetableFromBytesWithWarnings(source, options)
+	return timetable, err
+}
+
+func TimetableFromReaderWithWarnings(source io.Reader, options ParseOptions) (Timetable, []ParseWarning, error) {
 	var raw rawTimetable
 	if err := xml.NewDecoder(source).Decode(&raw); err != nil {
-		return Timetable{}, err
+		return Timetable{}, nil, err
 	}
-	return parseTimetable(raw), nil
+	return newTimetableParser(options).parse(raw)
 }
 
-func TimetableFromBytes(source []byte) (Timetable, error) {
+func TimetableFromBytesWithWarnings(source []byte, options ParseOptions) (Timetable, []ParseWarning, error) {
 	var raw rawTimetable
 	if err := xml.Unmarshal(source, &raw); err != nil {
-		return Timetable{}, err
+		return Timetable{}, nil, err
+	}
+	return newTimetableParser(options).parse(raw)
+}
+
+type timetableParser struct {
+	options  ParseOptions
+	logger   Logger
+	warnings []ParseWarning
+}
+
+func newTimetableParser(options ParseOptions) *timetableParser {
+	return &timetableParser{
+		options: options,
+		logger:  loggerOrNop(options.Logger),
 	}
-	return parseTimetable(raw), nil
+}
+
+func (p *timetableParser) parse(data rawTimetable) (Timetable, []ParseWarning, error) {
+	timetable := p.parseTimetable(data)
+	if p.options.Strict && len(p.warnings) > 0 {
+		return timetable, p.warnings, &UnknownCodeError{Warnings: p.warnings}
+	}
+	return timetable, p.warnings, nil
+}
+
+func (p *timetableParser) unknown(kind string, path string, value string) {
+	warning := ParseWarning{
+		Path:  path,
+		Type:  kind,
+		Value: value,
+	}
+	p.warnings = append(p.warnings, warning)
+	p.logger.Log(LogLevelWarn, "Could not parse "+kind, LogField{"type", kind}, LogField{"path", path}, LogField{"value", value})
+}
+
+func indexPath(path string, index int) string {
+	return fmt.Sprintf("%s[%d]", path, index)
+}
+
+func parseTimetable(data rawTimetable) Timetable {
+	return newTimetableParser(ParseOptions{}).parseTimetable(data)
 }
 
 type rawTimetable struct {
@@ -30,12 +117,12 @@ type rawTimetable struct {
 	Messages []rawMessage       `xml:"m,omitempty"`
 }
 
-func parseTimetable(data rawTimetable) Timetable {
+func (p *timetableParser) parseTimetable(data rawTimetable) Timetable {
 	return Timetable{
 		Station:  data.Station,
 		EvaId:    data.EvaId,
-		Stops:    parseTimetableStops(data.Stops),
-		Messages: parseMessages(data.Messages),
+		Stops:    p.parseTimetableStops(data.Stops, "stops"),
+		Messages: p.parseMessages(data.Messages, "messages"),
 	}
 }
 
@@ -58,22 +145,23 @@ type rawMessage struct {
 	TripLabel           []rawTripLabel          `xml:"tl,omitempty"`
 }
 
-func parseMessages(data []rawMessage) []Message {
+func (p *timetableParser) parseMessages(data []rawMessage, path string) []Message {
 	result := make([]Message, len(data))
 	for i, element := range data {
-		result[i] = parseMessage(element)
+		result[i] = p.parseMessage(element, indexPath(path, i))
 	}
 	return result
 }
 
-func parseMessage(data rawMessage) Message {
+func (p *timetableParser) parseMessage(data rawMessage, path string) Message {
 	var code int
 	if data.Code != nil {
 		code = *data.Code
 	}
 	return Message{
 		MessageId:           data.MessageId,
-		Type:                parseMessageType(data.Type),
+		Type:                p.parseMessageType(data.Type, path+".type"),
+		RawType:             string(data.Type),
 		From:                data.From.Value(),
 		To:                  data.To.Value(),
 		Code:                code,
@@ -82,9 +170,10 @@ func parseMessage(data rawMessage) Message {
 		Category:            data.Category,
 		ExternalCategory:    data.ExternalCategory,
 		Timestamp:           data.Timestamp.Value(),
-		Priority:            parsePriority(data.Priority),
+		Priority:            p.parsePriority(data.Priority, path+".priority"),
+		RawPriority:         string(data.Priority),
 		Deleted:             data.Deleted != 0,
-		DistributorMessages: parseDistributorMessages(data.DistributorMessages),
+		DistributorMessages: p.parseDistributorMessages(data.DistributorMessages, path+".distributor_messages"),
 	}
 }
 
@@ -102,7 +191,7 @@ const (
 	rawMessageTypeUndefined               rawMessageType = ""
 )
 
-func parseMessageType(data rawMessageType) MessageType {
+func (p *timetableParser) parseMessageType(data rawMessageType, path string) MessageType {
 	switch data {
 	case rawMessageTypeHafasInformationManager:
 		return MessageTypeHafasInformationManager
@@ -123,7 +212,7 @@ func parseMessageType(data rawMessageType) MessageType {
 	case rawMessageTypeUndefined:
 		return MessageTypeUndefined
 	default:
-		fmt.Printf("Could not parse MessageType '%s'\n", data)
+		p.unknown("MessageType", path, string(data))
 		return MessageTypeUnknown
 	}
 }
@@ -138,7 +227,7 @@ const (
 	rawPriorityUndefined rawPriority = ""
 )
 
-func parsePriority(data rawPriority) Priority {
+func (p *timetableParser) parsePriority(data rawPriority, path string) Priority {
 	switch data {
 	case rawPriorityHigh:
 		return PriorityHigh
@@ -151,7 +240,7 @@ func parsePriority(data rawPriority) Priority {
 	case rawPriorityUndefined:
 		return PriorityUndefined
 	default:
-		fmt.Printf("Could not parse Priority '%s'\n", data)
+		p.unknown("Priority", path, string(data))
 		return PriorityUnknown
 	}
 }
@@ -166,7 +255,7 @@ const (
 	rawDistributorTypeUndefined    rawDistributorType = ""
 )
 
-func parseDistributorType(data rawDistributorType) DistributorType {
+func (p *timetableParser) parseDistributorType(data rawDistributorType, path string) DistributorType {
 	switch data {
 	case rawDistributorTypeCity:
 		return DistributorTypeCity
@@ -179,7 +268,7 @@ func parseDistributorType(data rawDistributorType) DistributorType {
 	case rawDistributorTypeUndefined:
 		return DistributorTypeUndefined
 	default:
-		fmt.Printf("Could not parse DistributorType '%s'\n", data)
+		p.unknown("DistributorType", path, string(data))
 		return DistributorTypeUnknown
 	}
 }
@@ -191,20 +280,21 @@ type rawDistributorMessage struct {
 	Timestamp       *timeShort         `xml:"ts,attr,omitempty"`
 }
 
-func parseDistributorMessages(data []rawDistributorMessage) []DistributorMessage {
+func (p *timetableParser) parseDistributorMessages(data []rawDistributorMessage, path string) []DistributorMessage {
 	result := make([]DistributorMessage, len(data))
 	for i, element := range data {
-		result[i] = parseDistributorMessage(element)
+		result[i] = p.parseDistributorMessage(element, indexPath(path, i))
 	}
 	return result
 }
 
-func parseDistributorMessage(data rawDistributorMessage) DistributorMessage {
+func (p *timetableParser) parseDistributorMessage(data rawDistributorMessage, path string) DistributorMessage {
 	return DistributorMessage{
-		DistributorType: parseDistributorType(data.DistributorType),
-		DistributorName: data.DistributorName,
-		InternalText:    data.InternalText,
-		Timestamp:       data.Timestamp.Value(),
+		DistributorType:    p.parseDistributorType(data.DistributorType, path+".distributor_type"),
+		RawDistributorType: string(data.DistributorType),
+		DistributorName:    data.DistributorName,
+		InternalText:       data.InternalText,
+		Timestamp:          data.Timestamp.Value(),
 	}
 }
 
@@ -221,41 +311,41 @@ type rawTimetableStop struct {
 	Connections             []rawConnection             `xml:"conn,omitempty"`
 }
 
-func parseTimetableStops(data []rawTimetableStop) []TimetableStop {
+func (p *timetableParser) parseTimetableStops(data []rawTimetableStop, path string) []TimetableStop {
 	result := make([]TimetableStop, len(data))
 	for i, element := range data {
-		result[i] = parseTimetableStop(element)
+		result[i] = p.parseTimetableStop(element, indexPath(path, i))
 	}
 	return result
 }
 
-func parseTimetableStop(data rawTimetableStop) TimetableStop {
+func (p *timetableParser) parseTimetableStop(data rawTimetableStop, path string) TimetableStop {
 	var ref *TimetableStop
 	if data.Ref != nil {
-		it := parseTimetableStop(*data.Ref)
+		it := p.parseTimetableStop(*data.Ref, path+".ref")
 		ref = &it
 	}
 	var arrival *Event
 	if data.Arrival != nil {
-		it := parseEvent(*data.Arrival)
+		it := p.parseEvent(*data.Arrival, path+".arrival")
 		arrival = &it
 	}
 	var departure *Event
 	if data.Departure != nil {
-		it := parseEvent(*data.Departure)
+		it := p.parseEvent(*data.Departure, path+".departure")
 		departure = &it
 	}
 	return TimetableStop{
 		StopId:                  data.StopId,
 		EvaId:                   data.EvaId,
-		TripLabel:               parseTripLabel(data.TripLabel),
+		TripLabel:               p.parseTripLabel(data.TripLabel, path+".trip_label"),
 		Ref:                     ref,
 		Arrival:                 arrival,
 		Departure:               departure,
-		Messages:                parseMessages(data.Messages),
-		HistoricDelays:          parseHistoricDelays(data.HistoricDelays),
-		HistoricPlatformChanges: parseHistoricPlatformChanges(data.HistoricPlatformChanges),
-		Connections:             parseConnections(data.Connections),
+		Messages:                p.parseMessages(data.Messages, path+".messages"),
+		HistoricDelays:          p.parseHistoricDelays(data.HistoricDelays, path+".historic_delay"),
+		HistoricPlatformChanges: p.parseHistoricPlatformChanges(data.HistoricPlatformChanges),
+		Connections:             p.parseConnections(data.Connections, path+".connections"),
 	}
 }
 
@@ -269,15 +359,17 @@ type rawTripLabel struct {
 	TripNumber   string        `xml:"n,attr,omitempty"`
 }
 
-func parseTripLabel(data rawTripLabel) TripLabel {
+func (p *timetableParser) parseTripLabel(data rawTripLabel, path string) TripLabel {
 	return TripLabel{
-		Messages:     parseMessages(data.Messages),
-		CreatedAt:    data.CreatedAt.Value(),
-		FilterFlag:   parseFilterFlag(data.FilterFlag),
-		TripType:     parseTripType(data.TripType),
-		Owner:        data.Owner,
-		TripCategory: data.TripCategory,
-		TripNumber:   data.TripNumber,
+		Messages:      p.parseMessages(data.Messages, path+".messages"),
+		CreatedAt:     data.CreatedAt.Value(),
+		FilterFlag:    p.parseFilterFlag(data.FilterFlag, path+".filter_flag"),
+		RawFilterFlag: string(data.FilterFlag),
+		TripType:      p.parseTripType(data.TripType, path+".trip_type"),
+		RawTripType:   string(data.TripType),
+		Owner:         data.Owner,
+		TripCategory:  data.TripCategory,
+		TripNumber:    data.TripNumber,
 	}
 }
 
@@ -291,7 +383,7 @@ const (
 	rawFilterFlagUndefined    rawFilterFlag = ""
 )
 
-func parseFilterFlag(data rawFilterFlag) FilterFlag {
+func (p *timetableParser) parseFilterFlag(data rawFilterFlag, path string) FilterFlag {
 	switch data {
 	case rawFilterFlagExternal:
 		return FilterFlagExternal
@@ -304,7 +396,7 @@ func parseFilterFlag(data rawFilterFlag) FilterFlag {
 	case rawFilterFlagUndefined:
 		return FilterFlagUndefined
 	default:
-		fmt.Printf("Could not parse FilterFlag '%s'\n", data)
+		p.unknown("FilterFlag", path, string(data))
 		return FilterFlagUnknown
 	}
 }
@@ -321,7 +413,7 @@ const (
 	rawTripTypeUndefined rawTripType = ""
 )
 
-func parseTripType(data rawTripType) TripType {
+func (p *timetableParser) parseTripType(data rawTripType, path string) TripType {
 	switch data {
 	case rawTripTypeP:
 		return TripTypeP
@@ -338,7 +430,7 @@ func parseTripType(data rawTripType) TripType {
 	case rawTripTypeUndefined:
 		return TripTypeUndefined
 	default:
-		fmt.Printf("Could not parse TripType '%s'\n", data)
+		p.unknown("TripType", path, string(data))
 		return TripTypeUnknown
 	}
 }
@@ -351,20 +443,21 @@ type rawHistoricDelay struct {
 	Code      string         `xml:"cod,attr"`
 }
 
-func parseHistoricDelays(data []rawHistoricDelay) []HistoricDelay {
+func (p *timetableParser) parseHistoricDelays(data []rawHistoricDelay, path string) []HistoricDelay {
 	result := make([]HistoricDelay, len(data))
 	for i, element := range data {
-		result[i] = parseHistoricDelay(element)
+		result[i] = p.parseHistoricDelay(element, indexPath(path, i))
 	}
 	return result
 }
 
-func parseHistoricDelay(data rawHistoricDelay) HistoricDelay {
+func (p *timetableParser) parseHistoricDelay(data rawHistoricDelay, path string) HistoricDelay {
 	return HistoricDelay{
 		Timestamp: data.Timestamp.Value(),
 		Arrival:   data.Arrival.Value(),
 		Departure: data.Departure.Value(),
-		Source:    parseDelaySource(data.Source),
+		Source:    p.parseDelaySource(data.Source, path+".source"),
+		RawSource: string(data.Source),
 		Code:      data.Code,
 	}
 }
@@ -382,7 +475,7 @@ const (
 	rawDelaySourceUndefined     rawDelaySource = ""
 )
 
-func parseDelaySource(data rawDelaySource) DelaySource {
+func (p *timetableParser) parseDelaySource(data rawDelaySource, path string) DelaySource {
 	switch data {
 	case rawDelaySourceLeibit:
 		return DelaySourceLeibit
@@ -401,7 +494,7 @@ func parseDelaySource(data rawDelaySource) DelaySource {
 	case rawDelaySourceUndefined:
 		return DelaySourceUndefined
 	default:
-		fmt.Printf("Could not parse DelaySource '%s'\n", data)
+		p.unknown("DelaySource", path, string(data))
 		return DelaySourceUnknown
 	}
 }
@@ -413,15 +506,15 @@ type rawHistoricPlatformChange struct {
 	Cause             string     `xml:"cot,attr,omitempty"`
 }
 
-func parseHistoricPlatformChanges(data []rawHistoricPlatformChange) []HistoricPlatformChange {
+func (p *timetableParser) parseHistoricPlatformChanges(data []rawHistoricPlatformChange) []HistoricPlatformChange {
 	result := make([]HistoricPlatformChange, len(data))
 	for i, element := range data {
-		result[i] = parseHistoricPlatformChange(element)
+		result[i] = p.parseHistoricPlatformChange(element)
 	}
 	return result
 }
 
-func parseHistoricPlatformChange(data rawHistoricPlatformChange) HistoricPlatformChange {
+func (p *timetableParser) parseHistoricPlatformChange(data rawHistoricPlatformChange) HistoricPlatformChange {
 	return HistoricPlatformChange{
 		Timestamp:         data.Timestamp.Value(),
 		ArrivalPlatform:   data.ArrivalPlatform,
@@ -439,30 +532,31 @@ type rawConnection struct {
 	Stop             *rawTimetableStop   `xml:"s,omitempty"`
 }
 
-func parseConnections(data []rawConnection) []Connection {
+func (p *timetableParser) parseConnections(data []rawConnection, path string) []Connection {
 	result := make([]Connection, len(data))
 	for i, element := range data {
-		result[i] = parseConnection(element)
+		result[i] = p.parseConnection(element, indexPath(path, i))
 	}
 	return result
 }
 
-func parseConnection(data rawConnection) Connection {
+func (p *timetableParser) parseConnection(data rawConnection, path string) Connection {
 	var ref TimetableStop
 	if data.Ref != nil {
-		ref = parseTimetableStop(*data.Ref)
+		ref = p.parseTimetableStop(*data.Ref, path+".ref")
 	}
 	var stop TimetableStop
 	if data.Stop != nil {
-		stop = parseTimetableStop(*data.Stop)
+		stop = p.parseTimetableStop(*data.Stop, path+".stop")
 	}
 	return Connection{
-		ConnectionId:     data.ConnectionId,
-		Timestamp:        data.Timestamp.Value(),
-		EvaId:            data.EvaId,
-		ConnectionStatus: parseConnectionStatus(data.ConnectionStatus),
-		Ref:              &ref,
-		Stop:             &stop,
+		ConnectionId:        data.ConnectionId,
+		Timestamp:           data.Timestamp.Value(),
+		EvaId:               data.EvaId,
+		ConnectionStatus:    p.parseConnectionStatus(data.ConnectionStatus, path+".connection_status"),
+		RawConnectionStatus: string(data.ConnectionStatus),
+		Ref:                 &ref,
+		Stop:                &stop,
 	}
 }
 
@@ -475,7 +569,7 @@ const (
 	rawConnectionStatusUndefined   rawConnectionStatus = ""
 )
 
-func parseConnectionStatus(data rawConnectionStatus) ConnectionStatus {
+func (p *timetableParser) parseConnectionStatus(data rawConnectionStatus, path string) ConnectionStatus {
 	switch data {
 	case rawConnectionStatusWaiting:
 		return ConnectionStatusWaiting
@@ -486,7 +580,7 @@ func parseConnectionStatus(data rawConnectionStatus) ConnectionStatus {
 	case rawConnectionStatusUndefined:
 		return ConnectionStatusUndefined
 	default:
-		fmt.Printf("Could not parse ConnectionStatus '%s'\n", data)
+		p.unknown("ConnectionStatus", path, string(data))
 		return ConnectionStatusUnknown
 	}
 }
@@ -500,7 +594,7 @@ const (
 	rawEventStatusUndefined rawEventStatus = ""
 )
 
-func parseEventStatus(data rawEventStatus) EventStatus {
+func (p *timetableParser) parseEventStatus(data rawEventStatus, path string) EventStatus {
 	switch data {
 	case rawEventStatusAdded:
 		return EventStatusAdded
@@ -511,7 +605,7 @@ func parseEventStatus(data rawEventStatus) EventStatus {
 	case rawEventStatusUndefined:
 		return EventStatusUndefined
 	default:
-		fmt.Printf("Could not parse EventStatus '%s'\n", data)
+		p.unknown("EventStatus", path, string(data))
 		return EventStatusUnknown
 	}
 }
@@ -536,9 +630,9 @@ type rawEvent struct {
 	Transition         string          `xml:"tra,attr,omitempty"`
 }
 
-func parseEvent(data rawEvent) Event {
+func (p *timetableParser) parseEvent(data rawEvent, path string) Event {
 	return Event{
-		Messages:           parseMessages(data.Messages),
+		Messages:           p.parseMessages(data.Messages, path+".messages"),
 		PlannedPlatform:    data.PlannedPlatform,
 		PlannedTime:        data.PlannedTime.Value(),
 		PlannedPath:        data.PlannedPath.Value(),
@@ -547,8 +641,10 @@ func parseEvent(data rawEvent) Event {
 		ChangedTime:        data.ChangedTime.Value(),
 		ChangedPath:        data.ChangedPath.Value(),
 		ChangedDestination: data.ChangedDestination,
-		PlannedStatus:      parseEventStatus(data.PlannedStatus),
-		ChangedStatus:      parseEventStatus(data.ChangedStatus),
+		PlannedStatus:      p.parseEventStatus(data.PlannedStatus, path+".planned_status"),
+		RawPlannedStatus:   string(data.PlannedStatus),
+		ChangedStatus:      p.parseEventStatus(data.ChangedStatus, path+".changed_status"),
+		RawChangedStatus:   string(data.ChangedStatus),
 		Hidden:             data.Hidden != 0,
 		CancellationTime:   data.CancellationTime,
 		Wings:              data.Wings,
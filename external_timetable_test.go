@@ -0,0 +1,67 @@
+package bahn
+
+import (
+	"errors"
+	"testing"
+)
+
+const unknownCodesTimetable = `<timetable station="Hamburg Hbf">
+  <s id="-1-1904241307-1">
+    <tl f="X" t="p" o="80" c="ICE" n="1"/>
+    <ar pt="1904241540" ps="z">
+      <m id="r1" t="q" c="80" pr="9"/>
+    </ar>
+  </s>
+</timetable>`
+
+func TestParseWarnings(t *testing.T) {
+	timetable, warnings, err := TimetableFromBytesWithWarnings([]byte(unknownCodesTimetable), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timetable.Stops[0].TripLabel.FilterFlag != FilterFlagUnknown {
+		t.Errorf("expected unknown filter flag, got %s", timetable.Stops[0].TripLabel.FilterFlag)
+	}
+
+	expected := []ParseWarning{
+		{Path: "stops[0].arrival.messages[0].priority", Type: "Priority", Value: "9"},
+		{Path: "stops[0].arrival.planned_status", Type: "EventStatus", Value: "z"},
+		{Path: "stops[0].trip_label.filter_flag", Type: "FilterFlag", Value: "X"},
+	}
+	if len(warnings) != len(expected) {
+		t.Fatalf("expected %d warnings, got %v", len(expected), warnings)
+	}
+	for i := range expected {
+		if warnings[i] != expected[i] {
+			t.Errorf("expected warning %v, got %v", expected[i], warnings[i])
+		}
+	}
+
+	_, err = TimetableFromBytesWithOptions([]byte(unknownCodesTimetable), ParseOptions{Strict: true})
+	var unknownCodeError *UnknownCodeError
+	if !errors.As(err, &unknownCodeError) || len(unknownCodeError.Warnings) != len(expected) {
+		t.Errorf("expected strict parsing to fail, got %v", err)
+	}
+
+	if _, warnings, err = TimetableFromBytesWithWarnings(realtimeData, ParseOptions{Strict: true}); err != nil || len(warnings) != 0 {
+		t.Errorf("expected realtime fixture to parse without warnings, got %v %v", warnings, err)
+	}
+}
+
+func TestRawCodes(t *testing.T) {
+	timetable, err := TimetableFromBytes([]byte(unknownCodesTimetable))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := timetable.Stops[0]
+	if stop.TripLabel.RawFilterFlag != "X" || stop.TripLabel.RawTripType != "p" {
+		t.Errorf("unexpected raw trip label codes %+v", stop.TripLabel)
+	}
+	if stop.Arrival.PlannedStatus != EventStatusUnknown || stop.Arrival.RawPlannedStatus != "z" {
+		t.Errorf("unexpected planned status %s/%s", stop.Arrival.PlannedStatus, stop.Arrival.RawPlannedStatus)
+	}
+	if message := stop.Arrival.Messages[0]; message.RawType != "q" || message.RawPriority != "9" {
+		t.Errorf("unexpected raw message codes %+v", message)
+	}
+}
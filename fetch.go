@@ -0,0 +1,142 @@
+package bahn
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// EndpointPolicy configures caching per endpoint. With StaleIfError set,
+// a failed upstream request falls back to an expired cache entry up to that
+// age, which is returned together with a *StaleError.
+type EndpointPolicy struct {
+	NoCache      bool
+	TTL          time.Duration
+	StaleIfError time.Duration
+}
+
+func DefaultEndpointPolicy(endpoint Endpoint) EndpointPolicy {
+	switch endpoint {
+	case EndpointStation:
+		return EndpointPolicy{TTL: 24 * time.Hour}
+	case EndpointTimetable:
+		return EndpointPolicy{TTL: 6 * time.Hour}
+	case EndpointRealtimeAll:
+		return EndpointPolicy{TTL: 2 * time.Minute}
+	case EndpointRealtimeRecent:
+		return EndpointPolicy{TTL: 30 * time.Second}
+	case EndpointWingDefinition:
+		return EndpointPolicy{TTL: 24 * time.Hour}
+	case EndpointCoachSequence:
+		return EndpointPolicy{TTL: 5 * time.Minute}
+	case EndpointSuggestions:
+		return EndpointPolicy{TTL: time.Hour}
+	case EndpointHafasMessages:
+		return EndpointPolicy{TTL: 2 * time.Minute}
+	default:
+		return EndpointPolicy{}
+	}
+}
+
+type fetchRequest struct {
+	endpoint Endpoint
+	key      string
+	load     func(ctx context.Context) (interface{}, error)
+}
+
+func cacheKey(endpoint Endpoint, parts ...interface{}) string {
+	var builder strings.Builder
+	builder.WriteString(string(endpoint))
+	for _, part := range parts {
+		builder.WriteString(" ")
+		builder.WriteString(fmt.Sprint(part))
+	}
+	return builder.String()
+}
+
+func (c *ApiClient) policy(endpoint Endpoint) EndpointPolicy {
+	if policy, ok := c.Policies[endpoint]; ok {
+		return policy
+	}
+	return DefaultEndpointPolicy(endpoint)
+}
+
+func (c *ApiClient) fetch(ctx context.Context, request fetchRequest, result interface{}) error {
+	policy := c.policy(request.endpoint)
+	target := reflect.ValueOf(result).Elem()
+
+	if !policy.NoCache {
+		for _, cache := range c.Caches {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// Freshness is decided here with the client's clock, backends
+			// only drop entries past their own retention.
+			metadata, err := cacheGet(ctx, cache, request.key, result, true)
+			if err != nil || !metadata.Fresh(c.now()) {
+				continue
+			}
+			// Backends without metadata do not know when the value was stored,
+			// so the earlier tiers expire it as if it was loaded just now.
+			if metadata.StoredAt.IsZero() {
+				metadata = CacheMetadata{
+					StoredAt: c.now(),
+					TTL:      policy.TTL,
+				}
+			}
+			value := target.Interface()
+			for _, targetCache := range c.Caches {
+				if targetCache == cache {
+					break
+				}
+				_ = cacheSet(ctx, targetCache, request.key, value, metadata)
+			}
+			return nil
+		}
+	}
+
+	value, err := c.flights.do(ctx, request.key, func(ctx context.Context) (interface{}, error) {
+		value, err := request.load(ctx)
+		if err == nil && !policy.NoCache {
+			metadata := CacheMetadata{
+				StoredAt: c.now(),
+				TTL:      policy.TTL,
+			}
+			for _, cache := range c.Caches {
+				_ = cacheSet(ctx, cache, request.key, value, metadata)
+			}
+		}
+		return value, err
+	})
+	if err != nil {
+		if !policy.NoCache && policy.StaleIfError > 0 && ctx.Err() == nil {
+			if staleError := c.fetchStale(ctx, request.key, policy.StaleIfError, result, err); staleError != nil {
+				return staleError
+			}
+		}
+		target.Set(reflect.Zero(target.Type()))
+		return err
+	}
+	target.Set(reflect.ValueOf(value))
+	return nil
+}
+
+func (c *ApiClient) fetchStale(ctx context.Context, key string, maxAge time.Duration, result interface{}, cause error) *StaleError {
+	now := c.now()
+	for _, cache := range c.Caches {
+		metadata, err := cacheGet(ctx, cache, key, result, true)
+		if err != nil || metadata.StoredAt.IsZero() {
+			continue
+		}
+		if age := metadata.Age(now); age <= maxAge {
+			return &StaleError{
+				StoredAt: metadata.StoredAt,
+				Age:      age,
+				Err:      cause,
+			}
+		}
+	}
+	return nil
+}
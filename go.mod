@@ -1,10 +1,9 @@
 module git.kuschku.de/justJanne/bahn-api
 
-go 1.12
+go 1.13
 
 require (
 	github.com/andybalholm/cascadia v1.0.0
-	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b
 	golang.org/x/net v0.0.0-20180218175443-cbe0f9307d01
 	golang.org/x/text v0.3.2 // indirect
 	gopkg.in/yaml.v2 v2.2.2
@@ -0,0 +1,78 @@
+package bahn
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+}
+
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+type Logger interface {
+	Log(level LogLevel, message string, fields ...LogField)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(level LogLevel, message string, fields ...LogField) {}
+
+// StdLogger writes log entries at or above MinLevel to a standard library
+// logger as "LEVEL message key=value ...".
+type StdLogger struct {
+	Logger   *log.Logger
+	MinLevel LogLevel
+}
+
+func (l StdLogger) Log(level LogLevel, message string, fields ...LogField) {
+	if level < l.MinLevel {
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString(level.String())
+	builder.WriteString(" ")
+	builder.WriteString(message)
+	for _, field := range fields {
+		builder.WriteString(fmt.Sprintf(" %s=%v", field.Key, field.Value))
+	}
+
+	if l.Logger != nil {
+		l.Logger.Print(builder.String())
+	} else {
+		log.Print(builder.String())
+	}
+}
+
+func loggerOrNop(logger Logger) Logger {
+	if logger == nil {
+		return nopLogger{}
+	}
+	return logger
+}
@@ -0,0 +1,164 @@
+package bahn
+
+type MessageCodeCategory string
+
+const (
+	MessageCodeCategoryExternal      MessageCodeCategory = "EXTERNAL"
+	MessageCodeCategoryStaff         MessageCodeCategory = "STAFF"
+	MessageCodeCategoryWeather       MessageCodeCategory = "WEATHER"
+	MessageCodeCategoryTechnical     MessageCodeCategory = "TECHNICAL"
+	MessageCodeCategoryConstruction  MessageCodeCategory = "CONSTRUCTION"
+	MessageCodeCategoryOperations    MessageCodeCategory = "OPERATIONS"
+	MessageCodeCategoryService       MessageCodeCategory = "SERVICE"
+	MessageCodeCategoryFormation     MessageCodeCategory = "FORMATION"
+	MessageCodeCategoryReservation   MessageCodeCategory = "RESERVATION"
+	MessageCodeCategoryAccessibility MessageCodeCategory = "ACCESSIBILITY"
+	MessageCodeCategoryCapacity      MessageCodeCategory = "CAPACITY"
+	MessageCodeCategoryOther         MessageCodeCategory = "OTHER"
+)
+
+// MessageCode describes a numeric IRIS message code. Codes of cause of delay
+// messages and quality change messages share one number space.
+//
+// The codes and German texts follow the list collected by the
+// Travel::Status::DE::IRIS project, the English texts and categories are our
+// own. Codes missing from that list are left out, so their lookup fails.
+type MessageCode struct {
+	Code     int
+	Type     MessageType
+	Category MessageCodeCategory
+	German   string
+	English  string
+}
+
+// Text returns the German text for language "de" and the English text for
+// all other languages.
+func (c MessageCode) Text(language string) string {
+	if language == "de" {
+		return c.German
+	}
+	return c.English
+}
+
+func LookupMessageCode(code int) (MessageCode, bool) {
+	messageCode, ok := messageCodes[code]
+	return messageCode, ok
+}
+
+// CodeInfo looks up the code of cause of delay and quality change messages.
+func (m Message) CodeInfo() (MessageCode, bool) {
+	if m.Type != MessageTypeCauseOfDelay && m.Type != MessageTypeQualityChange {
+		return MessageCode{}, false
+	}
+	messageCode, ok := LookupMessageCode(m.Code)
+	if !ok || messageCode.Type != m.Type {
+		return MessageCode{}, false
+	}
+	return messageCode, true
+}
+
+func delayCode(code int, category MessageCodeCategory, german string, english string) MessageCode {
+	return MessageCode{code, MessageTypeCauseOfDelay, category, german, english}
+}
+
+func qualityCode(code int, category MessageCodeCategory, german string, english string) MessageCode {
+	return MessageCode{code, MessageTypeQualityChange, category, german, english}
+}
+
+var messageCodes = indexMessageCodes(
+	delayCode(2, MessageCodeCategoryExternal, "Polizeieinsatz", "Police operation"),
+	delayCode(3, MessageCodeCategoryExternal, "Feuerwehreinsatz an der Strecke", "Fire brigade operation near the track"),
+	delayCode(4, MessageCodeCategoryStaff, "Kurzfristiger Personalausfall", "Short-notice staff shortage"),
+	delayCode(5, MessageCodeCategoryExternal, "Ärztliche Versorgung eines Fahrgastes", "Medical care of a passenger"),
+	delayCode(6, MessageCodeCategoryExternal, "Unbefugtes Ziehen der Notbremse", "Unauthorised use of the emergency brake"),
+	delayCode(7, MessageCodeCategoryExternal, "Unbefugte Personen auf der Strecke", "Unauthorised persons on the track"),
+	delayCode(8, MessageCodeCategoryExternal, "Notarzteinsatz auf der Strecke", "Emergency doctor on the track"),
+	delayCode(9, MessageCodeCategoryStaff, "Streik", "Strike"),
+	delayCode(10, MessageCodeCategoryExternal, "Tiere auf der Strecke", "Animals on the line"),
+	delayCode(11, MessageCodeCategoryWeather, "Unwetter", "Severe weather"),
+	delayCode(12, MessageCodeCategoryOperations, "Warten auf ein verspätetes Schiff", "Waiting for a delayed ship"),
+	delayCode(13, MessageCodeCategoryOperations, "Pass- und Zollkontrolle", "Passport and customs control"),
+	delayCode(14, MessageCodeCategoryTechnical, "Technische Störung am Bahnhof", "Technical fault at the station"),
+	delayCode(15, MessageCodeCategoryExternal, "Beeinträchtigung durch Vandalismus", "Vandalism"),
+	delayCode(16, MessageCodeCategoryExternal, "Entschärfung einer Fliegerbombe", "Defusing of a wartime bomb"),
+	delayCode(17, MessageCodeCategoryTechnical, "Beschädigung einer Brücke", "Damage to a bridge"),
+	delayCode(18, MessageCodeCategoryWeather, "Umgestürzter Baum auf der Strecke", "Fallen tree on the line"),
+	delayCode(19, MessageCodeCategoryExternal, "Unfall an einem Bahnübergang", "Accident at a level crossing"),
+	delayCode(20, MessageCodeCategoryExternal, "Tiere im Gleis", "Animals on the track"),
+	delayCode(21, MessageCodeCategoryOperations, "Warten auf Fahrgäste aus einem anderen Zug", "Waiting for passengers from another train"),
+	delayCode(22, MessageCodeCategoryWeather, "Witterungsbedingte Störung", "Weather-related disruption"),
+	delayCode(23, MessageCodeCategoryExternal, "Feuerwehreinsatz auf Bahngelände", "Fire brigade operation on railway premises"),
+	delayCode(24, MessageCodeCategoryOperations, "Verspätung im Ausland", "Delay abroad"),
+	delayCode(25, MessageCodeCategoryOperations, "Bereitstellung weiterer Wagen", "Provision of additional coaches"),
+	delayCode(28, MessageCodeCategoryExternal, "Gegenstände auf der Strecke", "Objects on the line"),
+	delayCode(29, MessageCodeCategoryOperations, "Ersatzverkehr mit Bus ist eingerichtet", "Replacement bus service"),
+	delayCode(31, MessageCodeCategoryConstruction, "Bauarbeiten", "Construction work"),
+	delayCode(32, MessageCodeCategoryOperations, "Verzögerung beim Ein-/Ausstieg", "Delay while boarding or alighting"),
+	delayCode(33, MessageCodeCategoryTechnical, "Reparatur an der Oberleitung", "Overhead line repair"),
+	delayCode(34, MessageCodeCategoryTechnical, "Reparatur an einem Signal", "Signal repair"),
+	delayCode(35, MessageCodeCategoryConstruction, "Streckensperrung", "Line closure"),
+	delayCode(36, MessageCodeCategoryTechnical, "Reparatur am Zug", "Repair to the train"),
+	delayCode(37, MessageCodeCategoryTechnical, "Reparatur am Wagen", "Repair to a coach"),
+	delayCode(38, MessageCodeCategoryTechnical, "Reparatur an der Strecke", "Track repair"),
+	delayCode(39, MessageCodeCategoryOperations, "Anhängen von zusätzlichen Wagen", "Attaching of additional coaches"),
+	delayCode(40, MessageCodeCategoryTechnical, "Defektes Stellwerk", "Signal box failure"),
+	delayCode(41, MessageCodeCategoryTechnical, "Technische Störung an einem Bahnübergang", "Technical fault at a level crossing"),
+	delayCode(42, MessageCodeCategoryConstruction, "Außerplanmäßige Geschwindigkeitsbeschränkung", "Temporary speed restriction"),
+	delayCode(43, MessageCodeCategoryOperations, "Verspätung eines vorausfahrenden Zuges", "Delay of a preceding train"),
+	delayCode(44, MessageCodeCategoryOperations, "Warten auf einen entgegenkommenden Zug", "Waiting for an oncoming train"),
+	delayCode(45, MessageCodeCategoryOperations, "Überholung durch einen anderen Zug", "Overtaken by another train"),
+	delayCode(46, MessageCodeCategoryOperations, "Warten auf freie Einfahrt", "Waiting for a free platform"),
+	delayCode(47, MessageCodeCategoryOperations, "Verspätete Bereitstellung des Zuges", "Late provision of the train"),
+	delayCode(48, MessageCodeCategoryOperations, "Verspätung aus vorheriger Fahrt", "Delay from a previous journey"),
+	delayCode(55, MessageCodeCategoryTechnical, "Technische Störung an einem anderen Zug", "Technical fault on another train"),
+	delayCode(56, MessageCodeCategoryOperations, "Warten auf Fahrgäste aus einem Bus", "Waiting for passengers from a bus"),
+	delayCode(57, MessageCodeCategoryOperations, "Zusätzlicher Halt zum Ein-/Ausstieg", "Additional stop for boarding or alighting"),
+	delayCode(58, MessageCodeCategoryOperations, "Umleitung des Zuges", "Train diverted"),
+	delayCode(59, MessageCodeCategoryWeather, "Schnee und Eis", "Snow and ice"),
+	delayCode(60, MessageCodeCategoryWeather, "Reduzierte Geschwindigkeit wegen Sturm", "Reduced speed due to storm"),
+	delayCode(61, MessageCodeCategoryTechnical, "Türstörung", "Door fault"),
+	delayCode(62, MessageCodeCategoryTechnical, "Behobene technische Störung am Zug", "Resolved technical fault on the train"),
+	delayCode(63, MessageCodeCategoryTechnical, "Technische Untersuchung am Zug", "Technical inspection of the train"),
+	delayCode(64, MessageCodeCategoryTechnical, "Weichenstörung", "Points failure"),
+	delayCode(65, MessageCodeCategoryWeather, "Erdrutsch", "Landslide"),
+	delayCode(66, MessageCodeCategoryWeather, "Hochwasser", "Flooding"),
+	delayCode(67, MessageCodeCategoryExternal, "Behördliche Anordnung", "Official order"),
+	delayCode(68, MessageCodeCategoryOperations, "Hohes Fahrgastaufkommen verlängert Ein- und Ausstieg", "High passenger numbers prolong boarding and alighting"),
+	delayCode(69, MessageCodeCategoryOperations, "Zug verkehrt mit verminderter Geschwindigkeit", "Train running at reduced speed"),
+	qualityCode(70, MessageCodeCategoryService, "WLAN nicht verfügbar", "Wi-Fi not available"),
+	qualityCode(71, MessageCodeCategoryService, "WLAN in einzelnen Wagen nicht verfügbar", "Wi-Fi not available in some coaches"),
+	qualityCode(72, MessageCodeCategoryService, "Info-/Entertainment nicht verfügbar", "Info and entertainment not available"),
+	qualityCode(73, MessageCodeCategoryFormation, "Heute: Mehrzweckabteil vorne", "Today: multi-purpose compartment at the front"),
+	qualityCode(74, MessageCodeCategoryFormation, "Heute: Mehrzweckabteil hinten", "Today: multi-purpose compartment at the rear"),
+	qualityCode(75, MessageCodeCategoryFormation, "Heute: 1. Klasse vorne", "Today: first class at the front"),
+	qualityCode(76, MessageCodeCategoryFormation, "Heute: 1. Klasse hinten", "Today: first class at the rear"),
+	qualityCode(77, MessageCodeCategoryFormation, "Ohne 1. Klasse", "No first class"),
+	qualityCode(79, MessageCodeCategoryFormation, "Ohne Mehrzweckabteil", "No multi-purpose compartment"),
+	qualityCode(80, MessageCodeCategoryFormation, "Abweichende Wagenreihung", "Coaches in a different order"),
+	qualityCode(82, MessageCodeCategoryFormation, "Mehrere Wagen fehlen", "Several coaches missing"),
+	qualityCode(83, MessageCodeCategoryAccessibility, "Defekte fahrzeuggebundene Einstiegshilfe", "Boarding aid on the train out of order"),
+	qualityCode(84, MessageCodeCategoryFormation, "Zug verkehrt richtig gereiht", "Coaches in the usual order"),
+	qualityCode(85, MessageCodeCategoryFormation, "Ein Wagen fehlt", "One coach missing"),
+	qualityCode(86, MessageCodeCategoryReservation, "Gesamter Zug ohne Reservierung", "No reservations on the entire train"),
+	qualityCode(87, MessageCodeCategoryReservation, "Einzelne Wagen ohne Reservierung", "No reservations in some coaches"),
+	qualityCode(88, MessageCodeCategoryOther, "Keine Qualitätsmängel", "No quality issues"),
+	qualityCode(89, MessageCodeCategoryReservation, "Reservierungen sind wieder vorhanden", "Reservations are available again"),
+	qualityCode(90, MessageCodeCategoryService, "Kein gastronomisches Angebot", "No catering"),
+	qualityCode(91, MessageCodeCategoryAccessibility, "Fahrradmitnahme nicht möglich", "Bicycles cannot be taken"),
+	qualityCode(92, MessageCodeCategoryAccessibility, "Eingeschränkte Fahrradbeförderung", "Limited space for bicycles"),
+	qualityCode(93, MessageCodeCategoryAccessibility, "Behindertengerechte Einrichtung fehlt", "Facilities for disabled passengers missing"),
+	qualityCode(94, MessageCodeCategoryService, "Ersatzbewirtschaftung", "Replacement catering"),
+	qualityCode(95, MessageCodeCategoryAccessibility, "Ohne behindertengerechtes WC", "No accessible toilet"),
+	qualityCode(96, MessageCodeCategoryCapacity, "Überbesetzung mit Kulanzleistungen", "Overcrowded, goodwill compensation offered"),
+	qualityCode(97, MessageCodeCategoryCapacity, "Überbesetzung ohne Kulanzleistungen", "Overcrowded, no goodwill compensation"),
+	qualityCode(98, MessageCodeCategoryOther, "Sonstige Qualitätsmängel", "Other quality issues"),
+	delayCode(99, MessageCodeCategoryOperations, "Verzögerungen im Betriebsablauf", "Operational delays"),
+)
+
+func indexMessageCodes(codes ...MessageCode) map[int]MessageCode {
+	result := make(map[int]MessageCode, len(codes))
+	for _, code := range codes {
+		result[code.Code] = code
+	}
+	return result
+}
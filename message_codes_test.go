@@ -0,0 +1,39 @@
+package bahn
+
+import "testing"
+
+func TestMessageCodes(t *testing.T) {
+	for code, german := range map[int]string{
+		31: "Bauarbeiten",
+		80: "Abweichende Wagenreihung",
+		84: "Zug verkehrt richtig gereiht",
+	} {
+		messageCode, ok := LookupMessageCode(code)
+		if !ok || messageCode.Text("de") != german || messageCode.Text("en") == "" {
+			t.Errorf("unexpected code %d: %+v", code, messageCode)
+		}
+	}
+	if _, ok := LookupMessageCode(52); ok {
+		t.Errorf("expected unknown code not to be found")
+	}
+	if messageCode, _ := LookupMessageCode(31); messageCode.Category != MessageCodeCategoryConstruction {
+		t.Errorf("expected construction category, got %s", messageCode.Category)
+	}
+
+	realtime, err := TimetableFromBytes(realtimeData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stop := range realtime.Stops {
+		for _, message := range stopMessages(stop) {
+			_, ok := message.CodeInfo()
+			if expected := message.Type == MessageTypeCauseOfDelay || message.Type == MessageTypeQualityChange; ok != expected {
+				t.Errorf("unexpected lookup result %t for %s message with code %d", ok, message.Type, message.Code)
+			}
+		}
+	}
+
+	if _, ok := (Message{Type: MessageTypeQualityChange, Code: 31}).CodeInfo(); ok {
+		t.Errorf("expected code of a different message type not to match")
+	}
+}
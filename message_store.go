@@ -0,0 +1,224 @@
+package bahn
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MessageStore aggregates messages from timetables and change feeds. Messages
+// are merged by MessageId keeping the version with the latest timestamp, and
+// deletions are kept as tombstones so repeated older versions do not bring a
+// message back. Messages are pruned automatically once expired or not added
+// again for messageRetention. It is safe for concurrent use.
+type MessageStore struct {
+	Clock Clock
+
+	mutex    sync.Mutex
+	messages map[string]*storedMessage
+	prunedAt time.Time
+}
+
+// Tombstones have to be kept as long as the feeds may still repeat older
+// versions of a message, which they stop doing well within a day.
+const messageRetention = 24 * time.Hour
+const messagePruneInterval = 10 * time.Minute
+
+type storedMessage struct {
+	message  Message
+	seenAt   time.Time
+	stations map[int64]bool
+	// stops maps the stop ids to the station the stop belongs to
+	stops map[string]int64
+}
+
+func NewMessageStore() *MessageStore {
+	return &MessageStore{}
+}
+
+// AddTimetable adds the messages of the station and of all stops, including
+// those attached to trip labels and events. Stops are filed under their own
+// station if set, otherwise under the station of the timetable.
+func (s *MessageStore) AddTimetable(timetable Timetable) {
+	s.AddStationMessages(timetable.EvaId, timetable.Messages...)
+	for _, stop := range timetable.Stops {
+		evaId := stop.EvaId
+		if evaId == 0 {
+			evaId = timetable.EvaId
+		}
+		s.AddStopMessages(evaId, stop.StopId, stopMessages(stop)...)
+	}
+}
+
+func (s *MessageStore) AddStationMessages(evaId int64, messages ...Message) {
+	s.add(messages, func(stored *storedMessage) {
+		stored.stations[evaId] = true
+	})
+}
+
+func (s *MessageStore) AddStopMessages(evaId int64, stopId string, messages ...Message) {
+	s.add(messages, func(stored *storedMessage) {
+		stored.stops[stopId] = evaId
+	})
+}
+
+func (s *MessageStore) add(messages []Message, attach func(stored *storedMessage)) {
+	now := clockOrSystem(s.Clock).Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.messages == nil {
+		s.messages = make(map[string]*storedMessage)
+		s.prunedAt = now
+	} else if now.Sub(s.prunedAt) >= messagePruneInterval {
+		s.prune(now)
+	}
+
+	for _, message := range messages {
+		if message.MessageId == "" {
+			continue
+		}
+		stored, ok := s.messages[message.MessageId]
+		if !ok {
+			stored = &storedMessage{
+				message:  message,
+				stations: make(map[int64]bool),
+				stops:    make(map[string]int64),
+			}
+			s.messages[message.MessageId] = stored
+		} else if messageNewer(message, stored.message) {
+			stored.message = message
+		}
+		stored.seenAt = now
+		attach(stored)
+	}
+}
+
+// messageNewer reports whether message replaces the stored version: a
+// deletion is only undone by a version with a newer timestamp.
+func messageNewer(message Message, stored Message) bool {
+	if messageOlder(message, stored) {
+		return false
+	}
+	if stored.Deleted && !message.Deleted {
+		return message.Timestamp != nil && stored.Timestamp != nil && message.Timestamp.After(*stored.Timestamp)
+	}
+	return true
+}
+
+func (s *MessageStore) Message(messageId string) (Message, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.messages[messageId]
+	if !ok {
+		return Message{}, false
+	}
+	return stored.message, true
+}
+
+// StopMessages returns the active messages attached to the stop.
+func (s *MessageStore) StopMessages(stopId string) []Message {
+	return s.active(func(stored *storedMessage) bool {
+		_, ok := stored.stops[stopId]
+		return ok
+	})
+}
+
+// StationMessages returns the active messages attached to the station itself,
+// not those of its stops.
+func (s *MessageStore) StationMessages(evaId int64) []Message {
+	return s.active(func(stored *storedMessage) bool {
+		return stored.stations[evaId]
+	})
+}
+
+// BoardMessages returns the active messages attached to the station or any
+// of its stops.
+func (s *MessageStore) BoardMessages(evaId int64) []Message {
+	return s.active(func(stored *storedMessage) bool {
+		if stored.stations[evaId] {
+			return true
+		}
+		for _, stopEvaId := range stored.stops {
+			if stopEvaId == evaId {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// active returns the matching messages which are neither deleted nor done and
+// valid now, ordered by priority and then newest first.
+func (s *MessageStore) active(matches func(stored *storedMessage) bool) []Message {
+	now := clockOrSystem(s.Clock).Now()
+
+	s.mutex.Lock()
+	var result []Message
+	for _, stored := range s.messages {
+		if matches(stored) && messageActive(stored.message, now) {
+			result = append(result, stored.message)
+		}
+	}
+	s.mutex.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if priorityRank(a.Priority) != priorityRank(b.Priority) {
+			return priorityRank(a.Priority) < priorityRank(b.Priority)
+		}
+		if !timeEqual(a.Timestamp, b.Timestamp) {
+			return b.Timestamp == nil || (a.Timestamp != nil && a.Timestamp.After(*b.Timestamp))
+		}
+		return a.MessageId < b.MessageId
+	})
+	return result
+}
+
+// Prune drops messages which expired before now and messages, including
+// tombstones, which have not been added again for messageRetention. This
+// also happens automatically while adding messages.
+func (s *MessageStore) Prune() {
+	now := clockOrSystem(s.Clock).Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.prune(now)
+}
+
+func (s *MessageStore) prune(now time.Time) {
+	for messageId, stored := range s.messages {
+		expired := !stored.message.Deleted && stored.message.To != nil && stored.message.To.Before(now)
+		if expired || now.Sub(stored.seenAt) >= messageRetention {
+			delete(s.messages, messageId)
+		}
+	}
+	s.prunedAt = now
+}
+
+func messageActive(message Message, now time.Time) bool {
+	if message.Deleted || message.Priority == PriorityDone {
+		return false
+	}
+	if message.From != nil && now.Before(*message.From) {
+		return false
+	}
+	if message.To != nil && now.After(*message.To) {
+		return false
+	}
+	return true
+}
+
+func priorityRank(priority Priority) int {
+	switch priority {
+	case PriorityHigh:
+		return 0
+	case PriorityMedium:
+		return 1
+	case PriorityLow:
+		return 2
+	default:
+		return 3
+	}
+}
@@ -0,0 +1,140 @@
+package bahn
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMessageStore(t *testing.T) {
+	realtime, err := TimetableFromBytes(realtimeData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewMessageStore()
+	store.Clock = FixedClock(time.Date(2019, 4, 24, 15, 0, 0, 0, Location))
+
+	var wait sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			store.AddTimetable(realtime)
+		}()
+	}
+	wait.Wait()
+
+	messages := store.StopMessages("-1588936129122896413-1904241456-11")
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 de-duplicated messages, got %+v", messages)
+	}
+	if messages[0].MessageId != "r48635962" {
+		t.Errorf("expected newest message first, got %s", messages[0].MessageId)
+	}
+
+	stationMessages := []Message{
+		{MessageId: "h1", Priority: PriorityLow, Timestamp: timePointer(time.Date(2019, 4, 24, 10, 0, 0, 0, Location))},
+		{MessageId: "h2", Priority: PriorityHigh, Timestamp: timePointer(time.Date(2019, 4, 24, 9, 0, 0, 0, Location))},
+		{MessageId: "h3", Priority: PriorityHigh, To: timePointer(time.Date(2019, 4, 24, 12, 0, 0, 0, Location))},
+	}
+	store.AddStationMessages(8000001, stationMessages...)
+	assertMessageIds(t, store.StationMessages(8000001), "h2", "h1")
+
+	store.AddStationMessages(8000001, Message{MessageId: "h2", Deleted: true, Timestamp: timePointer(time.Date(2019, 4, 24, 11, 0, 0, 0, Location))})
+	store.AddStationMessages(8000001, stationMessages[1])
+	assertMessageIds(t, store.StationMessages(8000001), "h1")
+
+	store.AddStationMessages(8000001, Message{MessageId: "h1", Priority: PriorityHigh, Timestamp: timePointer(time.Date(2019, 4, 24, 11, 0, 0, 0, Location))})
+	if message, _ := store.Message("h1"); message.Priority != PriorityHigh {
+		t.Errorf("expected latest version to win, got %+v", message)
+	}
+
+	board := make(map[string]bool)
+	for _, message := range store.BoardMessages(8002549) {
+		board[message.MessageId] = true
+	}
+	for _, message := range append(store.StationMessages(8002549), messages...) {
+		if !board[message.MessageId] {
+			t.Errorf("expected board to include %s", message.MessageId)
+		}
+	}
+	if len(store.StationMessages(8002549)) == 0 || board["h1"] {
+		t.Errorf("unexpected board messages %v", board)
+	}
+
+	store.Prune()
+	if message, ok := store.Message("h2"); !ok || !message.Deleted {
+		t.Errorf("expected tombstone to be kept, got %+v", message)
+	}
+	if _, ok := store.Message("h3"); ok {
+		t.Errorf("expected expired message to be pruned")
+	}
+
+	store.Clock = FixedClock(time.Date(2019, 4, 25, 15, 0, 0, 0, Location))
+	store.AddStationMessages(8000001, Message{MessageId: "h4"})
+	for _, messageId := range []string{"h1", "h2", "r48635962"} {
+		if _, ok := store.Message(messageId); ok {
+			t.Errorf("expected %s to be pruned after the retention", messageId)
+		}
+	}
+	assertMessageIds(t, store.StationMessages(8000001), "h4")
+}
+
+func TestMessageStorePlan(t *testing.T) {
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write(timetableData)
+	})
+	defer closeServer()
+
+	plan, err := client.Timetable(8002549, time.Date(2019, 4, 24, 15, 0, 0, 0, Location))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan.Stops[0].Messages = append(plan.Stops[0].Messages, Message{MessageId: "p1", Priority: PriorityHigh})
+
+	// change feeds only carry the eva id on their stops
+	changes, err := TimetableFromBytes(realtimeData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes.EvaId = 0
+	stop := findStop(t, changes, "-1588936129122896413-1904241456-11")
+
+	store := NewMessageStore()
+	store.Clock = FixedClock(time.Date(2019, 4, 24, 15, 0, 0, 0, Location))
+	store.AddTimetable(plan)
+	store.AddTimetable(changes)
+
+	board := make(map[string]bool)
+	for _, message := range store.BoardMessages(8002549) {
+		board[message.MessageId] = true
+	}
+	unfiled := make(map[string]bool)
+	for _, message := range store.BoardMessages(0) {
+		unfiled[message.MessageId] = true
+	}
+	for _, message := range append(stopMessages(stop), plan.Stops[0].Messages...) {
+		if !board[message.MessageId] || unfiled[message.MessageId] {
+			t.Errorf("expected %s to be filed under the station", message.MessageId)
+		}
+	}
+}
+
+func timePointer(value time.Time) *time.Time {
+	return &value
+}
+
+func assertMessageIds(t *testing.T, messages []Message, messageIds ...string) {
+	t.Helper()
+	if len(messages) != len(messageIds) {
+		t.Errorf("expected messages %v, got %+v", messageIds, messages)
+		return
+	}
+	for i, messageId := range messageIds {
+		if messages[i].MessageId != messageId {
+			t.Errorf("expected message %d to be %s, got %s", i, messageId, messages[i].MessageId)
+		}
+	}
+}
@@ -12,6 +12,7 @@ type Timetable struct {
 type Message struct {
 	MessageId           string               `json:"message_id,omitempty"yaml:"message_id,omitempty"`
 	Type                MessageType          `json:"type,omitempty"yaml:"type,omitempty"`
+	RawType             string               `json:"raw_type,omitempty" yaml:"raw_type,omitempty"`
 	From                *time.Time           `json:"from,omitempty"yaml:"from,omitempty"`
 	To                  *time.Time           `json:"to,omitempty"yaml:"to,omitempty"`
 	Code                int                  `json:"code,omitempty"yaml:"code,omitempty"`
@@ -21,6 +22,7 @@ type Message struct {
 	ExternalCategory    string               `json:"external_category,omitempty"yaml:"external_category,omitempty"`
 	Timestamp           *time.Time           `json:"timestamp,omitempty"yaml:"timestamp,omitempty"`
 	Priority            Priority             `json:"priority,omitempty"yaml:"priority,omitempty"`
+	RawPriority         string               `json:"raw_priority,omitempty" yaml:"raw_priority,omitempty"`
 	Owner               string               `json:"owner,omitempty"yaml:"owner,omitempty"`
 	ExternalLink        string               `json:"external_link,omitempty"yaml:"external_link,omitempty"`
 	Deleted             bool                 `json:"deleted,omitempty"yaml:"deleted,omitempty"`
@@ -65,10 +67,11 @@ const (
 )
 
 type DistributorMessage struct {
-	DistributorType DistributorType `json:"distributor_type,omitempty"yaml:"distributor_type,omitempty"`
-	DistributorName string          `json:"distributor_name,omitempty"yaml:"distributor_name,omitempty"`
-	InternalText    string          `json:"internal_text,omitempty"yaml:"internal_text,omitempty"`
-	Timestamp       *time.Time      `json:"timestamp,omitempty"yaml:"timestamp,omitempty"`
+	DistributorType    DistributorType `json:"distributor_type,omitempty"yaml:"distributor_type,omitempty"`
+	RawDistributorType string          `json:"raw_distributor_type,omitempty" yaml:"raw_distributor_type,omitempty"`
+	DistributorName    string          `json:"distributor_name,omitempty"yaml:"distributor_name,omitempty"`
+	InternalText       string          `json:"internal_text,omitempty"yaml:"internal_text,omitempty"`
+	Timestamp          *time.Time      `json:"timestamp,omitempty"yaml:"timestamp,omitempty"`
 }
 
 type TimetableStop struct {
@@ -85,13 +88,15 @@ type TimetableStop struct {
 }
 
 type TripLabel struct {
-	Messages     []Message  `json:"messages,omitempty"yaml:"messages,omitempty"`
-	CreatedAt    *time.Time `json:"created_at,omitempty"yaml:"created_at,omitempty"`
-	FilterFlag   FilterFlag `json:"filter_flag,omitempty"yaml:"filter_flag,omitempty"`
-	TripType     TripType   `json:"trip_type,omitempty"yaml:"trip_type,omitempty"`
-	Owner        string     `json:"owner,omitempty"yaml:"owner,omitempty"`
-	TripCategory string     `json:"trip_category,omitempty"yaml:"trip_category,omitempty"`
-	TripNumber   string     `json:"trip_number,omitempty"yaml:"trip_number,omitempty"`
+	Messages      []Message  `json:"messages,omitempty"yaml:"messages,omitempty"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"yaml:"created_at,omitempty"`
+	FilterFlag    FilterFlag `json:"filter_flag,omitempty"yaml:"filter_flag,omitempty"`
+	RawFilterFlag string     `json:"raw_filter_flag,omitempty" yaml:"raw_filter_flag,omitempty"`
+	TripType      TripType   `json:"trip_type,omitempty"yaml:"trip_type,omitempty"`
+	RawTripType   string     `json:"raw_trip_type,omitempty" yaml:"raw_trip_type,omitempty"`
+	Owner         string     `json:"owner,omitempty"yaml:"owner,omitempty"`
+	TripCategory  string     `json:"trip_category,omitempty"yaml:"trip_category,omitempty"`
+	TripNumber    string     `json:"trip_number,omitempty"yaml:"trip_number,omitempty"`
 }
 
 type FilterFlag string
@@ -123,6 +128,7 @@ type HistoricDelay struct {
 	Arrival   *time.Time  `json:"arrival,omitempty"yaml:"arrival,omitempty"`
 	Departure *time.Time  `json:"departure,omitempty"yaml:"departure,omitempty"`
 	Source    DelaySource `json:"source,omitempty"yaml:"source,omitempty"`
+	RawSource string      `json:"raw_source,omitempty" yaml:"raw_source,omitempty"`
 	Code      string      `json:"code,omitempty"yaml:"code,omitempty"`
 }
 
@@ -147,12 +153,13 @@ type HistoricPlatformChange struct {
 	Cause             string     `json:"cause,omitempty"yaml:"cause,omitempty"`
 }
 type Connection struct {
-	ConnectionId     string           `json:"connection_id,omitempty"yaml:"connection_id,omitempty"`
-	Timestamp        *time.Time       `json:"timestamp,omitempty"yaml:"timestamp,omitempty"`
-	EvaId            int64            `json:"eva_id,omitempty"yaml:"eva_id,omitempty"`
-	ConnectionStatus ConnectionStatus `json:"connection_status,omitempty"yaml:"connection_status,omitempty"`
-	Ref              *TimetableStop   `json:"ref,omitempty"yaml:"ref,omitempty"`
-	Stop             *TimetableStop   `json:"stop,omitempty"yaml:"stop,omitempty"`
+	ConnectionId        string           `json:"connection_id,omitempty"yaml:"connection_id,omitempty"`
+	Timestamp           *time.Time       `json:"timestamp,omitempty"yaml:"timestamp,omitempty"`
+	EvaId               int64            `json:"eva_id,omitempty"yaml:"eva_id,omitempty"`
+	ConnectionStatus    ConnectionStatus `json:"connection_status,omitempty"yaml:"connection_status,omitempty"`
+	RawConnectionStatus string           `json:"raw_connection_status,omitempty" yaml:"raw_connection_status,omitempty"`
+	Ref                 *TimetableStop   `json:"ref,omitempty"yaml:"ref,omitempty"`
+	Stop                *TimetableStop   `json:"stop,omitempty"yaml:"stop,omitempty"`
 }
 
 type ConnectionStatus string
@@ -187,7 +194,9 @@ type Event struct {
 	ChangedPath        []string    `json:"changed_path,omitempty"yaml:"changed_path,omitempty"`
 	ChangedDestination string      `json:"changed_destination,omitempty"yaml:"changed_destination,omitempty"`
 	PlannedStatus      EventStatus `json:"planned_status,omitempty"yaml:"planned_status,omitempty"`
+	RawPlannedStatus   string      `json:"raw_planned_status,omitempty" yaml:"raw_planned_status,omitempty"`
 	ChangedStatus      EventStatus `json:"changed_status,omitempty"yaml:"changed_status,omitempty"`
+	RawChangedStatus   string      `json:"raw_changed_status,omitempty" yaml:"raw_changed_status,omitempty"`
 	Hidden             bool        `json:"hidden,omitempty"yaml:"hidden,omitempty"`
 	CancellationTime   string      `json:"cancellation_time,omitempty"yaml:"cancellation_time,omitempty"`
 	Wings              string      `json:"wings,omitempty"yaml:"wings,omitempty"`
@@ -0,0 +1,16 @@
+package bahn
+
+import "time"
+
+type Trip struct {
+	TripId    int64      `json:"trip_id,omitempty" yaml:"trip_id,omitempty"`
+	TripStart time.Time  `json:"trip_start,omitempty" yaml:"trip_start,omitempty"`
+	TripLabel TripLabel  `json:"trip_label,omitempty" yaml:"trip_label,omitempty"`
+	Stops     []TripStop `json:"stops,omitempty" yaml:"stops,omitempty"`
+}
+
+type TripStop struct {
+	Station string         `json:"station,omitempty" yaml:"station,omitempty"`
+	EvaId   int64          `json:"eva_id,omitempty" yaml:"eva_id,omitempty"`
+	Stop    *TimetableStop `json:"stop,omitempty" yaml:"stop,omitempty"`
+}
@@ -0,0 +1,158 @@
+package bahn
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Upstream string
+
+const (
+	UpstreamIris          Upstream = "iris"
+	UpstreamCoachSequence Upstream = "coach_sequence"
+	UpstreamHafas         Upstream = "hafas"
+)
+
+func (e Endpoint) Upstream() Upstream {
+	switch e {
+	case EndpointCoachSequence:
+		return UpstreamCoachSequence
+	case EndpointSuggestions, EndpointHafasMessages:
+		return UpstreamHafas
+	default:
+		return UpstreamIris
+	}
+}
+
+// RateLimit configures a token bucket refilled with RequestsPerSecond up to
+// Burst tokens, and an upper bound on concurrent requests. With FailFast set,
+// requests exceeding the budget fail with ErrRateLimitExceeded instead of
+// waiting for their context to allow it.
+type RateLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+	MaxConcurrent     int
+	FailFast          bool
+}
+
+type rateLimiters struct {
+	mutex    sync.Mutex
+	limiters map[Upstream]*rateLimiter
+}
+
+func (r *rateLimiters) get(upstream Upstream, limits map[Upstream]RateLimit) *rateLimiter {
+	limit, ok := limits[upstream]
+	if !ok {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.limiters == nil {
+		r.limiters = make(map[Upstream]*rateLimiter)
+	}
+	limiter, ok := r.limiters[upstream]
+	if !ok || limiter.limit != limit {
+		limiter = newRateLimiter(limit)
+		r.limiters[upstream] = limiter
+	}
+	return limiter
+}
+
+type rateLimiter struct {
+	limit RateLimit
+	slots chan struct{}
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	limiter := &rateLimiter{
+		limit:  limit,
+		tokens: float64(limit.burst()),
+		last:   time.Now(),
+	}
+	if limit.MaxConcurrent > 0 {
+		limiter.slots = make(chan struct{}, limit.MaxConcurrent)
+	}
+	return limiter
+}
+
+func (l RateLimit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return 1
+}
+
+func (l *rateLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if l.slots != nil {
+		if l.limit.FailFast {
+			select {
+			case l.slots <- struct{}{}:
+			default:
+				return nil, ErrRateLimitExceeded
+			}
+		} else {
+			select {
+			case l.slots <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	if err := l.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	for {
+		l.mutex.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.limit.RequestsPerSecond
+		if burst := float64(l.limit.burst()); l.tokens > burst {
+			l.tokens = burst
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mutex.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.limit.RequestsPerSecond * float64(time.Second))
+		l.mutex.Unlock()
+
+		if l.limit.FailFast {
+			return ErrRateLimitExceeded
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
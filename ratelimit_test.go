@@ -0,0 +1,128 @@
+package bahn
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimitBurst(t *testing.T) {
+	limiter := newRateLimiter(RateLimit{RequestsPerSecond: 0.001, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		release, err := limiter.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("expected request %d within burst, got %v", i, err)
+		}
+		release()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected exhausted burst to wait until the deadline, got %v", err)
+	}
+}
+
+func TestRateLimitFailFast(t *testing.T) {
+	var requests int32
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = writer.Write(realtimeData)
+	})
+	defer closeServer()
+
+	client.RateLimits = map[Upstream]RateLimit{
+		UpstreamIris: {RequestsPerSecond: 0.001, Burst: 1, FailFast: true},
+	}
+	date := time.Date(2019, 4, 24, 15, 0, 0, 0, time.UTC)
+	if _, err := client.RealtimeAll(8002549, date); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := client.RealtimeRecent(8002549, date)
+	var upstreamError *UpstreamError
+	if !errors.Is(err, ErrRateLimitExceeded) || !errors.As(err, &upstreamError) {
+		t.Fatalf("expected rate limit UpstreamError, got %v", err)
+	}
+	if upstreamError.Endpoint != EndpointRealtimeRecent {
+		t.Errorf("unexpected error details %+v", upstreamError)
+	}
+	if requests != 1 {
+		t.Errorf("expected a single upstream request, got %d", requests)
+	}
+
+	// other upstreams have their own budget
+	if _, err := client.limiters.get(UpstreamHafas, client.RateLimits).acquire(context.Background()); err != nil {
+		t.Errorf("expected unlimited upstream, got %v", err)
+	}
+}
+
+func TestRateLimitConcurrency(t *testing.T) {
+	limiter := newRateLimiter(RateLimit{MaxConcurrent: 1})
+
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		release, err := limiter.acquire(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- release
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected second request to wait for a free slot")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case release := <-acquired:
+		release()
+	case <-time.After(time.Second):
+		t.Fatal("expected second request to proceed once the slot is released")
+	}
+}
+
+func TestRateLimitCancellation(t *testing.T) {
+	release := make(chan struct{})
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		<-release
+		_, _ = writer.Write(realtimeData)
+	})
+	defer closeServer()
+
+	client.RateLimits = map[Upstream]RateLimit{
+		UpstreamIris: {MaxConcurrent: 1},
+	}
+	date := time.Date(2019, 4, 24, 15, 0, 0, 0, time.UTC)
+
+	done := make(chan error)
+	go func() {
+		_, err := client.RealtimeAll(8002549, date)
+		done <- err
+	}()
+	for len(client.limiters.get(UpstreamIris, client.RateLimits).slots) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.RealtimeRecentContext(ctx, 8002549, date); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline while waiting for a slot, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
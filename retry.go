@@ -0,0 +1,108 @@
+package bahn
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy configures retries of idempotent requests. Jitter is the
+// fraction of each backoff which is randomized, a Retry-After sent by the
+// upstream is honoured if it is longer than the computed backoff.
+type RetryPolicy struct {
+	MaxAttempts          int
+	InitialBackoff       time.Duration
+	MaxBackoff           time.Duration
+	Multiplier           float64
+	Jitter               float64
+	RetryableStatusCodes []int
+	Retryable            func(err error) bool
+}
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p == nil {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	var upstreamError *UpstreamError
+	if !errors.As(err, &upstreamError) {
+		return false
+	}
+	switch {
+	case upstreamError.StatusCode == 0:
+		return upstreamError.Kind == ErrUpstreamUnavailable
+	case upstreamError.Kind == ErrMalformedPayload || upstreamError.Kind == ErrEmptyResponse:
+		return false
+	}
+
+	statusCodes := p.RetryableStatusCodes
+	if statusCodes == nil {
+		statusCodes = defaultRetryableStatusCodes
+	}
+	for _, statusCode := range statusCodes {
+		if upstreamError.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+
+	if backoff := time.Duration(delay); backoff > retryAfter {
+		return backoff
+	}
+	return retryAfter
+}
+
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
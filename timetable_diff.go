@@ -0,0 +1,169 @@
+package bahn
+
+import "time"
+
+type TimetableChangeType string
+
+const (
+	TimetableChangeStopAdded       TimetableChangeType = "STOP_ADDED"
+	TimetableChangeStopRemoved     TimetableChangeType = "STOP_REMOVED"
+	TimetableChangeTimeChanged     TimetableChangeType = "TIME_CHANGED"
+	TimetableChangePlatformChanged TimetableChangeType = "PLATFORM_CHANGED"
+	TimetableChangePathChanged     TimetableChangeType = "PATH_CHANGED"
+	TimetableChangeMessageAdded    TimetableChangeType = "MESSAGE_ADDED"
+	TimetableChangeMessageRemoved  TimetableChangeType = "MESSAGE_REMOVED"
+)
+
+type EventKind string
+
+const (
+	EventKindArrival   EventKind = "ARRIVAL"
+	EventKindDeparture EventKind = "DEPARTURE"
+	EventKindUndefined EventKind = ""
+)
+
+// TimetableChange is a single difference of a stop. Event tells whether it
+// concerns the arrival or departure, the Old and New fields matching Type
+// are set.
+type TimetableChange struct {
+	Type        TimetableChangeType
+	Event       EventKind
+	OldTime     *time.Time
+	NewTime     *time.Time
+	OldPlatform string
+	NewPlatform string
+	OldPath     []string
+	NewPath     []string
+	Message     *Message
+}
+
+type StopDiff struct {
+	StopId  string
+	Old     *TimetableStop
+	New     *TimetableStop
+	Changes []TimetableChange
+}
+
+// DiffTimetables compares two snapshots of a timetable by StopId, using the
+// effective time, platform and path of each event. Stops present in both with
+// no differences are omitted. Stops are ordered as in after, followed by the
+// removed stops as in before.
+func DiffTimetables(before Timetable, after Timetable) []StopDiff {
+	beforeStops := make(map[string]*TimetableStop, len(before.Stops))
+	for i := range before.Stops {
+		beforeStops[before.Stops[i].StopId] = &before.Stops[i]
+	}
+	afterStops := make(map[string]bool, len(after.Stops))
+
+	var result []StopDiff
+	for i := range after.Stops {
+		stop := &after.Stops[i]
+		afterStops[stop.StopId] = true
+
+		previous, ok := beforeStops[stop.StopId]
+		if !ok {
+			result = append(result, StopDiff{
+				StopId:  stop.StopId,
+				New:     stop,
+				Changes: []TimetableChange{{Type: TimetableChangeStopAdded}},
+			})
+			continue
+		}
+		if changes := DiffStops(*previous, *stop); len(changes) > 0 {
+			result = append(result, StopDiff{
+				StopId:  stop.StopId,
+				Old:     previous,
+				New:     stop,
+				Changes: changes,
+			})
+		}
+	}
+
+	for i := range before.Stops {
+		stop := &before.Stops[i]
+		if !afterStops[stop.StopId] {
+			result = append(result, StopDiff{
+				StopId:  stop.StopId,
+				Old:     stop,
+				Changes: []TimetableChange{{Type: TimetableChangeStopRemoved}},
+			})
+		}
+	}
+	return result
+}
+
+// DiffStops lists the differences between two versions of the same stop.
+func DiffStops(before TimetableStop, after TimetableStop) []TimetableChange {
+	var result []TimetableChange
+	result = append(result, diffEvents(EventKindArrival, before.Arrival, after.Arrival)...)
+	result = append(result, diffEvents(EventKindDeparture, before.Departure, after.Departure)...)
+
+	beforeMessages := messagesById(stopMessages(before))
+	afterMessages := messagesById(stopMessages(after))
+	for _, message := range stopMessages(after) {
+		if previous, ok := beforeMessages[message.MessageId]; message.Deleted || (ok && !previous.Deleted) {
+			continue
+		}
+		beforeMessages[message.MessageId] = message
+		message := message
+		result = append(result, TimetableChange{Type: TimetableChangeMessageAdded, Message: &message})
+	}
+	for _, message := range stopMessages(before) {
+		if current, ok := afterMessages[message.MessageId]; message.Deleted || (ok && !current.Deleted) {
+			continue
+		}
+		afterMessages[message.MessageId] = message
+		message := message
+		result = append(result, TimetableChange{Type: TimetableChangeMessageRemoved, Message: &message})
+	}
+	return result
+}
+
+func diffEvents(kind EventKind, before *Event, after *Event) []TimetableChange {
+	var result []TimetableChange
+	if oldTime, newTime := before.EffectiveTime(), after.EffectiveTime(); !timeEqual(oldTime, newTime) {
+		result = append(result, TimetableChange{
+			Type:    TimetableChangeTimeChanged,
+			Event:   kind,
+			OldTime: oldTime,
+			NewTime: newTime,
+		})
+	}
+	if oldPlatform, newPlatform := before.EffectivePlatform(), after.EffectivePlatform(); oldPlatform != newPlatform {
+		result = append(result, TimetableChange{
+			Type:        TimetableChangePlatformChanged,
+			Event:       kind,
+			OldPlatform: oldPlatform,
+			NewPlatform: newPlatform,
+		})
+	}
+	if oldPath, newPath := before.EffectivePath(), after.EffectivePath(); !pathEqual(oldPath, newPath) {
+		result = append(result, TimetableChange{
+			Type:    TimetableChangePathChanged,
+			Event:   kind,
+			OldPath: oldPath,
+			NewPath: newPath,
+		})
+	}
+	return result
+}
+
+func messagesById(messages []Message) map[string]Message {
+	result := make(map[string]Message, len(messages))
+	for _, message := range messages {
+		result[message.MessageId] = message
+	}
+	return result
+}
+
+func pathEqual(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package bahn
+
+import (
+	"testing"
+)
+
+func TestDiffTimetables(t *testing.T) {
+	before, err := TimetableFromBytes([]byte(`<timetable station="Hamburg Hbf">
+  <s id="1-1904241500-5">
+    <ar pt="1904241530" pp="5" ppth="Kiel Hbf|Neum&#252;nster"><m id="r1" t="d" c="36" ts="1904241400"/></ar>
+    <dp pt="1904241535" pp="5" ppth="Hannover Hbf|G&#246;ttingen"><m id="r1" t="d" c="36" ts="1904241400"/></dp>
+  </s>
+  <s id="2-1904241500-3"><dp pt="1904241550" pp="8"/></s>
+  <s id="3-1904241500-1"><dp pt="1904241555" pp="9"/></s>
+</timetable>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := TimetableFromBytes([]byte(`<timetable station="Hamburg Hbf">
+  <s id="4-1904241500-1"><dp pt="1904241558" pp="9"/></s>
+  <s id="1-1904241500-5">
+    <ar pt="1904241530" pp="5" ppth="Kiel Hbf|Neum&#252;nster"/>
+    <dp pt="1904241535" ct="1904241542" pp="5" cp="6" ppth="Hannover Hbf|G&#246;ttingen" cpth="Hannover Hbf">
+      <m id="r2" t="q" c="80" ts="1904241410"/>
+    </dp>
+  </s>
+  <s id="2-1904241500-3"><dp pt="1904241550" pp="8"/></s>
+</timetable>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := DiffTimetables(before, after)
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 changed stops, got %+v", diff)
+	}
+	if diff[0].StopId != "4-1904241500-1" || diff[0].Changes[0].Type != TimetableChangeStopAdded || diff[0].Old != nil {
+		t.Errorf("expected added stop, got %+v", diff[0])
+	}
+	if diff[2].StopId != "3-1904241500-1" || diff[2].Changes[0].Type != TimetableChangeStopRemoved || diff[2].New != nil {
+		t.Errorf("expected removed stop, got %+v", diff[2])
+	}
+
+	changes := diff[1].Changes
+	expected := []TimetableChangeType{
+		TimetableChangeTimeChanged,
+		TimetableChangePlatformChanged,
+		TimetableChangePathChanged,
+		TimetableChangeMessageAdded,
+		TimetableChangeMessageRemoved,
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for i, changeType := range expected {
+		if changes[i].Type != changeType {
+			t.Errorf("expected change %d to be %s, got %s", i, changeType, changes[i].Type)
+		}
+	}
+	if changes[0].Event != EventKindDeparture || changes[0].NewTime.Minute() != 42 {
+		t.Errorf("unexpected time change %+v", changes[0])
+	}
+	if changes[1].OldPlatform != "5" || changes[1].NewPlatform != "6" {
+		t.Errorf("unexpected platform change %+v", changes[1])
+	}
+	if changes[3].Message.MessageId != "r2" || changes[4].Message.MessageId != "r1" {
+		t.Errorf("unexpected message changes %+v %+v", changes[3].Message, changes[4].Message)
+	}
+
+	if diff := DiffTimetables(after, after); len(diff) != 0 {
+		t.Errorf("expected no differences, got %+v", diff)
+	}
+}
@@ -0,0 +1,190 @@
+package bahn
+
+import "time"
+
+// The helpers below resolve the planned and changed values of an Event. All of
+// them can be called on a nil *Event and return the zero value in that case.
+
+func (e *Event) EffectiveTime() *time.Time {
+	if e == nil {
+		return nil
+	}
+	if e.ChangedTime != nil {
+		return e.ChangedTime
+	}
+	return e.PlannedTime
+}
+
+// Delay is the difference between changed and planned time, or zero if
+// either is unknown.
+func (e *Event) Delay() time.Duration {
+	if e == nil || e.PlannedTime == nil || e.ChangedTime == nil {
+		return 0
+	}
+	return e.ChangedTime.Sub(*e.PlannedTime)
+}
+
+func (e *Event) IsCancelled() bool {
+	if e == nil {
+		return false
+	}
+	if e.ChangedStatus != EventStatusUndefined {
+		return e.ChangedStatus == EventStatusCancelled
+	}
+	return e.PlannedStatus == EventStatusCancelled
+}
+
+func (e *Event) IsAdded() bool {
+	if e == nil {
+		return false
+	}
+	return e.ChangedStatus == EventStatusAdded || e.PlannedStatus == EventStatusAdded
+}
+
+// EffectivePath is the changed path if there is one, otherwise the planned
+// path. Cancelled events keep their planned path.
+func (e *Event) EffectivePath() []string {
+	if e == nil {
+		return nil
+	}
+	if len(e.ChangedPath) > 0 && !e.IsCancelled() {
+		return pathStations(e.ChangedPath)
+	}
+	return pathStations(e.PlannedPath)
+}
+
+// CancelledStations are the stations of the planned path which are no longer
+// part of the changed path.
+func (e *Event) CancelledStations() []string {
+	if e == nil || len(e.ChangedPath) == 0 {
+		return nil
+	}
+
+	served := make(map[string]bool, len(e.ChangedPath))
+	for _, station := range e.ChangedPath {
+		served[station] = true
+	}
+	var result []string
+	for _, station := range pathStations(e.PlannedPath) {
+		if !served[station] {
+			result = append(result, station)
+		}
+	}
+	return result
+}
+
+// IsPartiallyCancelled reports whether the event still takes place, but some
+// stations of its planned path are no longer served.
+func (e *Event) IsPartiallyCancelled() bool {
+	return !e.IsCancelled() && len(e.CancelledStations()) > 0
+}
+
+func (e *Event) EffectivePlatform() string {
+	if e == nil {
+		return ""
+	}
+	if e.ChangedPlatform != "" {
+		return e.ChangedPlatform
+	}
+	return e.PlannedPlatform
+}
+
+func (e *Event) PlatformChanged() bool {
+	return e != nil && e.ChangedPlatform != "" && e.ChangedPlatform != e.PlannedPlatform
+}
+
+// EffectiveDestination is the changed or planned destination, which the
+// upstream only sets if it differs from the last station of the path.
+func (e *Event) EffectiveDestination() string {
+	if e == nil {
+		return ""
+	}
+	if e.ChangedDestination != "" {
+		return e.ChangedDestination
+	}
+	return e.PlannedDestination
+}
+
+// Event is the event a stop is listed under on a board: its departure, or its
+// arrival if the train ends here.
+func (s *TimetableStop) Event() *Event {
+	if s.Departure != nil {
+		return s.Departure
+	}
+	return s.Arrival
+}
+
+func (s *TimetableStop) EffectiveTime() *time.Time {
+	if eventTime := s.Departure.EffectiveTime(); eventTime != nil {
+		return eventTime
+	}
+	return s.Arrival.EffectiveTime()
+}
+
+func (s *TimetableStop) Delay() time.Duration {
+	return s.Event().Delay()
+}
+
+// IsCancelled reports whether all events of the stop are cancelled.
+func (s *TimetableStop) IsCancelled() bool {
+	if s.Arrival == nil && s.Departure == nil {
+		return false
+	}
+	return (s.Arrival == nil || s.Arrival.IsCancelled()) && (s.Departure == nil || s.Departure.IsCancelled())
+}
+
+// IsPartiallyCancelled reports whether the stop is served, but either one of
+// its events is cancelled or stations before or after it are no longer served.
+func (s *TimetableStop) IsPartiallyCancelled() bool {
+	if s.IsCancelled() {
+		return false
+	}
+	for _, event := range []*Event{s.Arrival, s.Departure} {
+		if event.IsCancelled() || event.IsPartiallyCancelled() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TimetableStop) EffectivePlatform() string {
+	return s.Event().EffectivePlatform()
+}
+
+func (s *TimetableStop) PlatformChanged() bool {
+	return s.Arrival.PlatformChanged() || s.Departure.PlatformChanged()
+}
+
+// Destination is the last station the train is going to serve, or empty if
+// it ends here.
+func (s *TimetableStop) Destination() string {
+	if destination := s.Departure.EffectiveDestination(); destination != "" {
+		return destination
+	}
+	path := s.Departure.EffectivePath()
+	if len(path) == 0 {
+		return ""
+	}
+	return path[len(path)-1]
+}
+
+// Origin is the first station the train has served, or empty if it starts
+// here.
+func (s *TimetableStop) Origin() string {
+	path := s.Arrival.EffectivePath()
+	if len(path) == 0 {
+		return ""
+	}
+	return path[0]
+}
+
+// pathStations drops the empty entry the upstream sends for an empty path.
+func pathStations(path []string) []string {
+	result := make([]string, 0, len(path))
+	for _, station := range path {
+		if station != "" {
+			result = append(result, station)
+		}
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package bahn
+
+import (
+	"testing"
+	"time"
+)
+
+func findStop(t *testing.T, timetable Timetable, stopId string) TimetableStop {
+	t.Helper()
+	for _, stop := range timetable.Stops {
+		if stop.StopId == stopId {
+			return stop
+		}
+	}
+	t.Fatalf("stop %s not found", stopId)
+	return TimetableStop{}
+}
+
+func TestEventHelpers(t *testing.T) {
+	realtime, err := TimetableFromBytes(realtimeData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diverted := findStop(t, realtime, "-1780366104452543204-1904240829-3")
+	if delay := diverted.Delay(); delay != 33*time.Minute {
+		t.Errorf("expected delay of 33m, got %s", delay)
+	}
+	if diverted.IsCancelled() || !diverted.IsPartiallyCancelled() {
+		t.Errorf("expected partial cancellation")
+	}
+	if cancelled := diverted.Departure.CancelledStations(); len(cancelled) != 1 || cancelled[0] != "Heidelberg Hbf" {
+		t.Errorf("unexpected cancelled stations %v", cancelled)
+	}
+	if diverted.EffectivePlatform() != "14" || diverted.PlatformChanged() {
+		t.Errorf("unexpected platform %s", diverted.EffectivePlatform())
+	}
+	if diverted.Destination() != "Offenburg" || diverted.Origin() != "Hamburg-Altona" {
+		t.Errorf("unexpected origin %s or destination %s", diverted.Origin(), diverted.Destination())
+	}
+
+	cancelled := findStop(t, realtime, "-6012005053401607387-1904240924-8")
+	if !cancelled.Departure.IsCancelled() || cancelled.Arrival.IsCancelled() {
+		t.Errorf("expected only the departure to be cancelled")
+	}
+	if cancelled.IsCancelled() || !cancelled.IsPartiallyCancelled() {
+		t.Errorf("expected stop to be partially cancelled")
+	}
+	if !cancelled.Arrival.PlatformChanged() || cancelled.Arrival.EffectivePlatform() != "14" {
+		t.Errorf("expected platform change to 14, got %s", cancelled.Arrival.EffectivePlatform())
+	}
+	if cancelled.Origin() != "Stuttgart Hbf" || cancelled.Destination() != "" {
+		t.Errorf("unexpected origin %s or destination %s", cancelled.Origin(), cancelled.Destination())
+	}
+
+	plan, err := TimetableFromBytes(timetableData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := findStop(t, MergeTimetables(plan, realtime), "-7676591997781413630-1904241307-19")
+	if merged.Delay() != 3*time.Minute || merged.Origin() != "Rostock Hbf" || merged.EffectivePlatform() != "7A-D" {
+		t.Errorf("unexpected merged stop %+v", merged.Arrival)
+	}
+	if expected := time.Date(2019, 4, 24, 15, 40, 0, 0, Location); !merged.EffectiveTime().Equal(expected) {
+		t.Errorf("expected effective time %s, got %s", expected, merged.EffectiveTime())
+	}
+
+	var missing *Event
+	if missing.Delay() != 0 || missing.IsCancelled() || missing.EffectiveTime() != nil {
+		t.Errorf("expected nil event helpers to return zero values")
+	}
+}
@@ -0,0 +1,282 @@
+package bahn
+
+import (
+	"context"
+	"time"
+)
+
+// MergeTimetables overlays the change feeds onto the planned timetable, in
+// order, so later changes win. Stops are matched by StopId; changed stops
+// without a planned counterpart are only included if they carry a trip label,
+// which is the case for added trains and trains planned outside of plan.
+// Messages are de-duplicated by id, deleted messages are dropped.
+func MergeTimetables(plan Timetable, changes ...Timetable) Timetable {
+	result := Timetable{
+		Station:  plan.Station,
+		EvaId:    plan.EvaId,
+		Stops:    make([]TimetableStop, 0, len(plan.Stops)),
+		Messages: mergeMessages(nil, plan.Messages),
+	}
+
+	index := make(map[string]int, len(plan.Stops))
+	for _, stop := range plan.Stops {
+		index[stop.StopId] = len(result.Stops)
+		result.Stops = append(result.Stops, mergeStop(TimetableStop{}, stop))
+	}
+
+	for _, change := range changes {
+		if result.Station == "" {
+			result.Station = change.Station
+		}
+		if result.EvaId == 0 {
+			result.EvaId = change.EvaId
+		}
+		result.Messages = mergeMessages(result.Messages, change.Messages)
+
+		for _, stop := range change.Stops {
+			if i, ok := index[stop.StopId]; ok {
+				result.Stops[i] = mergeStop(result.Stops[i], stop)
+			} else if stop.TripLabel.TripNumber != "" || stop.TripLabel.TripCategory != "" {
+				index[stop.StopId] = len(result.Stops)
+				result.Stops = append(result.Stops, mergeStop(TimetableStop{}, stop))
+			}
+		}
+	}
+	return result
+}
+
+func mergeStop(stop TimetableStop, change TimetableStop) TimetableStop {
+	if stop.StopId == "" {
+		stop.StopId = change.StopId
+	}
+	if stop.EvaId == 0 {
+		stop.EvaId = change.EvaId
+	}
+	stop.TripLabel = mergeTripLabel(stop.TripLabel, change.TripLabel)
+	if change.Ref != nil {
+		stop.Ref = change.Ref
+	}
+	stop.Arrival = mergeEvent(stop.Arrival, change.Arrival)
+	stop.Departure = mergeEvent(stop.Departure, change.Departure)
+	stop.Messages = mergeMessages(stop.Messages, change.Messages)
+	if len(change.HistoricDelays) > 0 {
+		stop.HistoricDelays = change.HistoricDelays
+	}
+	if len(change.HistoricPlatformChanges) > 0 {
+		stop.HistoricPlatformChanges = change.HistoricPlatformChanges
+	}
+	stop.Connections = mergeConnections(stop.Connections, change.Connections)
+	return stop
+}
+
+func mergeTripLabel(label TripLabel, change TripLabel) TripLabel {
+	if label.TripNumber == "" && label.TripCategory == "" {
+		label.CreatedAt = change.CreatedAt
+		label.FilterFlag = change.FilterFlag
+		label.RawFilterFlag = change.RawFilterFlag
+		label.TripType = change.TripType
+		label.RawTripType = change.RawTripType
+		label.Owner = change.Owner
+		label.TripCategory = change.TripCategory
+		label.TripNumber = change.TripNumber
+	}
+	label.Messages = mergeMessages(label.Messages, change.Messages)
+	return label
+}
+
+func mergeEvent(event *Event, change *Event) *Event {
+	if change == nil {
+		return event
+	}
+
+	var result Event
+	if event != nil {
+		result = *event
+	}
+
+	if result.PlannedPlatform == "" {
+		result.PlannedPlatform = change.PlannedPlatform
+	}
+	if result.PlannedTime == nil {
+		result.PlannedTime = change.PlannedTime
+	}
+	if len(result.PlannedPath) == 0 {
+		result.PlannedPath = change.PlannedPath
+	}
+	if result.PlannedDestination == "" {
+		result.PlannedDestination = change.PlannedDestination
+	}
+	if result.PlannedStatus == EventStatusUndefined {
+		result.PlannedStatus = change.PlannedStatus
+		result.RawPlannedStatus = change.RawPlannedStatus
+	}
+
+	if change.ChangedPlatform != "" {
+		result.ChangedPlatform = change.ChangedPlatform
+	}
+	if change.ChangedTime != nil {
+		result.ChangedTime = change.ChangedTime
+	}
+	if len(change.ChangedPath) > 0 {
+		result.ChangedPath = change.ChangedPath
+	}
+	if change.ChangedDestination != "" {
+		result.ChangedDestination = change.ChangedDestination
+	}
+	if change.ChangedStatus != EventStatusUndefined {
+		result.ChangedStatus = change.ChangedStatus
+		result.RawChangedStatus = change.RawChangedStatus
+	}
+	if change.CancellationTime != "" {
+		result.CancellationTime = change.CancellationTime
+	}
+
+	result.Hidden = result.Hidden || change.Hidden
+	if result.Wings == "" {
+		result.Wings = change.Wings
+	}
+	if result.Line == "" {
+		result.Line = change.Line
+	}
+	if result.Transition == "" {
+		result.Transition = change.Transition
+	}
+
+	result.Messages = mergeMessages(result.Messages, change.Messages)
+	return &result
+}
+
+// mergeMessages returns a new slice containing the messages of both lists,
+// where a message replaces an earlier one with the same id unless it has an
+// older timestamp. Deleted messages are removed.
+func mergeMessages(messages []Message, changes []Message) []Message {
+	if len(messages) == 0 && len(changes) == 0 {
+		return nil
+	}
+
+	result := make([]Message, 0, len(messages)+len(changes))
+	index := make(map[string]int, len(messages)+len(changes))
+	for _, list := range [][]Message{messages, changes} {
+		for _, message := range list {
+			if message.MessageId == "" {
+				result = append(result, message)
+				continue
+			}
+			if i, ok := index[message.MessageId]; ok {
+				if !messageOlder(message, result[i]) {
+					result[i] = message
+				}
+				continue
+			}
+			index[message.MessageId] = len(result)
+			result = append(result, message)
+		}
+	}
+
+	filtered := result[:0]
+	for _, message := range result {
+		if !message.Deleted {
+			filtered = append(filtered, message)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+	return filtered
+}
+
+func messageOlder(message Message, other Message) bool {
+	return message.Timestamp != nil && other.Timestamp != nil && message.Timestamp.Before(*other.Timestamp)
+}
+
+func mergeConnections(connections []Connection, changes []Connection) []Connection {
+	if len(changes) == 0 {
+		return connections
+	}
+
+	result := make([]Connection, 0, len(connections)+len(changes))
+	result = append(result, connections...)
+	index := make(map[string]int, len(result))
+	for i, connection := range result {
+		if connection.ConnectionId != "" {
+			index[connection.ConnectionId] = i
+		}
+	}
+	for _, connection := range changes {
+		if i, ok := index[connection.ConnectionId]; ok {
+			result[i] = connection
+			continue
+		}
+		if connection.ConnectionId != "" {
+			index[connection.ConnectionId] = len(result)
+		}
+		result = append(result, connection)
+	}
+	return result
+}
+
+func (c *ApiClient) MergedTimetable(evaId int64, date time.Time) (Timetable, error) {
+	return c.MergedTimetableContext(context.Background(), evaId, date)
+}
+
+// MergedTimetableContext returns the departure board for the hour containing
+// date, with the full and recent change feeds applied. Trains planned in the
+// previous hour are included if their changed time falls into this hour.
+func (c *ApiClient) MergedTimetableContext(ctx context.Context, evaId int64, date time.Time) (Timetable, error) {
+	start := date.In(Location).Truncate(time.Hour)
+	return c.mergedBoard(ctx, evaId, start, start.Add(time.Hour))
+}
+
+// mergedBoard returns stale data if any of the feeds could only be served
+// from cache, together with the oldest *StaleError.
+func (c *ApiClient) mergedBoard(ctx context.Context, evaId int64, from time.Time, to time.Time) (Timetable, error) {
+	var stale staleResults
+
+	plan, err := c.timetableHours(ctx, evaId, from.Add(-time.Hour), to)
+	if err = stale.add(err); err != nil {
+		return Timetable{}, err
+	}
+
+	all, err := c.RealtimeAllContext(ctx, evaId, from)
+	if err = stale.add(err); err != nil {
+		return Timetable{}, err
+	}
+	recent, err := c.RealtimeRecentContext(ctx, evaId, from)
+	if err = stale.add(err); err != nil {
+		return Timetable{}, err
+	}
+
+	merged := MergeTimetables(plan, all, recent)
+	merged.EvaId = evaId
+	merged.Stops = filterStops(merged.Stops, from, to)
+	return merged, stale.err()
+}
+
+func appendTimetable(timetable Timetable, other Timetable) Timetable {
+	if timetable.Station == "" {
+		timetable.Station = other.Station
+	}
+	if timetable.EvaId == 0 {
+		timetable.EvaId = other.EvaId
+	}
+	timetable.Stops = append(timetable.Stops, other.Stops...)
+	timetable.Messages = append(timetable.Messages, other.Messages...)
+	return timetable
+}
+
+// filterStops keeps the stops arriving or departing within [from, to).
+func filterStops(stops []TimetableStop, from time.Time, to time.Time) []TimetableStop {
+	result := make([]TimetableStop, 0, len(stops))
+	for _, stop := range stops {
+		for _, event := range []*Event{stop.Arrival, stop.Departure} {
+			if event == nil {
+				continue
+			}
+			if eventTime := event.EffectiveTime(); eventTime != nil && !eventTime.Before(from) && eventTime.Before(to) {
+				result = append(result, stop)
+				break
+			}
+		}
+	}
+	return result
+}
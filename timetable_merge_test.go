@@ -0,0 +1,191 @@
+package bahn
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const mergePlanTimetable = `<timetable station="Hamburg Hbf">
+  <s id="1-1904241500-5">
+    <tl f="F" t="p" o="80" c="ICE" n="1"/>
+    <ar pt="1904241530" pp="5"/>
+    <dp pt="1904241535" pp="5"/>
+  </s>
+</timetable>`
+
+const mergeChangesTimetable = `<timetable station="Hamburg Hbf" eva="8002549">
+  <s id="1-1904241500-5" eva="8002549">
+    <ar ct="1904241540" cp="7">
+      <m id="r1" t="d" c="36" ts="1904241400"/>
+    </ar>
+    <dp ct="1904241545" cp="7">
+      <m id="r1" t="d" c="36" ts="1904241400"/>
+      <m id="r2" t="q" c="80" ts="1904241400"/>
+    </dp>
+  </s>
+  <s id="2-1904241500-3" eva="8002549">
+    <tl f="F" t="e" o="80" c="ICE" n="2903"/>
+    <dp pt="1904241550" pp="8" cs="a"/>
+  </s>
+  <s id="3-1904241500-1" eva="8002549">
+    <dp ct="1904241555"/>
+  </s>
+</timetable>`
+
+const mergeRecentTimetable = `<timetable station="Hamburg Hbf" eva="8002549">
+  <s id="1-1904241500-5" eva="8002549">
+    <dp ct="1904241550">
+      <m id="r1" t="d" c="36" ts="1904241300"/>
+      <m id="r2" t="q" c="80" ts="1904241410" del="1"/>
+    </dp>
+  </s>
+</timetable>`
+
+func TestMergeTimetables(t *testing.T) {
+	var timetables []Timetable
+	for _, source := range []string{mergePlanTimetable, mergeChangesTimetable, mergeRecentTimetable} {
+		timetable, err := TimetableFromBytes([]byte(source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		timetables = append(timetables, timetable)
+	}
+
+	merged := MergeTimetables(timetables[0], timetables[1:]...)
+	if merged.EvaId != 8002549 || len(merged.Stops) != 2 {
+		t.Fatalf("unexpected merged timetable %+v", merged)
+	}
+
+	stop := merged.Stops[0]
+	if stop.TripLabel.TripNumber != "1" || stop.Arrival.PlannedPlatform != "5" || stop.Arrival.ChangedPlatform != "7" {
+		t.Errorf("unexpected arrival %+v", stop.Arrival)
+	}
+	departure := time.Date(2019, 4, 24, 15, 50, 0, 0, Location)
+	if stop.Departure.ChangedTime == nil || !stop.Departure.ChangedTime.Equal(departure) {
+		t.Errorf("expected recent changes to win, got %v", stop.Departure.ChangedTime)
+	}
+	if len(stop.Departure.Messages) != 1 || stop.Departure.Messages[0].Timestamp.Hour() != 14 {
+		t.Errorf("expected de-duplicated messages, got %+v", stop.Departure.Messages)
+	}
+
+	added := merged.Stops[1]
+	if added.StopId != "2-1904241500-3" || added.Departure.ChangedStatus != EventStatusAdded {
+		t.Errorf("expected added train, got %+v", added)
+	}
+
+	if len(timetables[0].Stops[0].Arrival.Messages) != 0 {
+		t.Errorf("expected plan to be left untouched")
+	}
+}
+
+func TestMergeTimetablesFixtures(t *testing.T) {
+	plan, err := TimetableFromBytes(timetableData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := TimetableFromBytes(realtimeData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := MergeTimetables(plan, changes)
+	for _, stop := range merged.Stops {
+		if stop.StopId != "-7676591997781413630-1904241307-19" {
+			continue
+		}
+		expected := time.Date(2019, 4, 24, 15, 40, 0, 0, Location)
+		if stop.Arrival.PlannedTime == nil || stop.Arrival.ChangedTime == nil || !stop.Arrival.ChangedTime.Equal(expected) {
+			t.Errorf("unexpected arrival %+v", stop.Arrival)
+		}
+		return
+	}
+	t.Errorf("expected stop to be merged")
+}
+
+func TestMergedTimetable(t *testing.T) {
+	responses := map[string]string{
+		"/timetable/plan/8002549/190424/14": `<timetable station="Hamburg Hbf">
+  <s id="4-1904241400-2"><tl f="N" t="p" o="800201" c="RE" n="7"/><dp pt="1904241455" pp="11"/></s>
+  <s id="5-1904241400-2"><tl f="N" t="p" o="800201" c="RE" n="8"/><dp pt="1904241450" pp="12"/></s>
+</timetable>`,
+		"/timetable/plan/8002549/190424/15": mergePlanTimetable,
+		"/timetable/fchg/8002549":           mergeChangesTimetable,
+		"/timetable/rchg/8002549": `<timetable station="Hamburg Hbf" eva="8002549">
+  <s id="4-1904241400-2" eva="8002549"><dp ct="1904241505"/></s>
+</timetable>`,
+	}
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		response, ok := responses[request.URL.Path]
+		if !ok {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = writer.Write([]byte(response))
+	})
+	defer closeServer()
+
+	timetable, err := client.MergedTimetable(8002549, time.Date(2019, 4, 24, 15, 20, 0, 0, Location))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stopIds := make(map[string]bool)
+	for _, stop := range timetable.Stops {
+		stopIds[stop.StopId] = true
+	}
+	if len(stopIds) != 3 || !stopIds["1-1904241500-5"] || !stopIds["2-1904241500-3"] || !stopIds["4-1904241400-2"] {
+		t.Errorf("unexpected stops %v", stopIds)
+	}
+}
+
+func TestMergedTimetableStale(t *testing.T) {
+	responses := map[string]string{
+		"/timetable/plan/8002549/190424/14": `<timetable station="Hamburg Hbf"/>`,
+		"/timetable/plan/8002549/190424/15": mergePlanTimetable,
+		"/timetable/fchg/8002549":           mergeChangesTimetable,
+		"/timetable/rchg/8002549":           `<timetable station="Hamburg Hbf" eva="8002549"/>`,
+	}
+	var available int32 = 1
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.LoadInt32(&available) == 0 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = writer.Write([]byte(responses[request.URL.Path]))
+	})
+	defer closeServer()
+
+	now := time.Date(2019, 4, 24, 15, 20, 0, 0, Location)
+	client.Clock = FixedClock(now)
+	memory := NewMemoryCache(0, 0)
+	memory.Clock = client.Clock
+	client.Caches = []CacheBackend{memory}
+	client.Policies = map[Endpoint]EndpointPolicy{
+		EndpointTimetable:      {TTL: time.Minute, StaleIfError: time.Hour},
+		EndpointRealtimeAll:    {TTL: time.Minute, StaleIfError: time.Hour},
+		EndpointRealtimeRecent: {TTL: time.Minute, StaleIfError: time.Hour},
+	}
+
+	fresh, err := client.MergedTimetable(8002549, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&available, 0)
+	client.Clock = FixedClock(now.Add(10 * time.Minute))
+	memory.Clock = client.Clock
+	stale, err := client.MergedTimetable(8002549, now)
+	var staleError *StaleError
+	if !errors.As(err, &staleError) || !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("expected stale result, got %v", err)
+	}
+	if staleError.Age != 10*time.Minute {
+		t.Errorf("expected stale result from 10m ago, got %s", staleError.Age)
+	}
+	if len(fresh.Stops) == 0 || len(stale.Stops) != len(fresh.Stops) {
+		t.Errorf("expected %d cached stops, got %d", len(fresh.Stops), len(stale.Stops))
+	}
+}
@@ -0,0 +1,123 @@
+package bahn
+
+import "context"
+
+type PathStationStatus string
+
+const (
+	PathStationServed    PathStationStatus = "SERVED"
+	PathStationCancelled PathStationStatus = "CANCELLED"
+	PathStationAdded     PathStationStatus = "ADDED"
+	PathStationDiverted  PathStationStatus = "DIVERTED"
+)
+
+// PathStation is a station of a path, EvaId is only set once resolved with
+// ResolvePath.
+type PathStation struct {
+	Name   string
+	EvaId  int64
+	Status PathStationStatus
+}
+
+// ComparePath aligns the planned and changed path. Stations in both are
+// served, planned stations missing from the changed path are cancelled.
+// Stations only in the changed path are diverted if they replace cancelled
+// stations, otherwise they are added. Cancelled stations are listed before
+// the stations replacing them.
+func ComparePath(planned []string, changed []string) []PathStation {
+	planned, changed = pathStations(planned), pathStations(changed)
+
+	// common[i][j] is the length of the longest common subsequence of
+	// planned[i:] and changed[j:].
+	common := make([][]int, len(planned)+1)
+	for i := range common {
+		common[i] = make([]int, len(changed)+1)
+	}
+	for i := len(planned) - 1; i >= 0; i-- {
+		for j := len(changed) - 1; j >= 0; j-- {
+			if planned[i] == changed[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	result := make([]PathStation, 0, len(planned)+len(changed))
+	var cancelled, inserted []string
+	flush := func() {
+		for _, name := range cancelled {
+			result = append(result, PathStation{Name: name, Status: PathStationCancelled})
+		}
+		status := PathStationAdded
+		if len(cancelled) > 0 {
+			status = PathStationDiverted
+		}
+		for _, name := range inserted {
+			result = append(result, PathStation{Name: name, Status: status})
+		}
+		cancelled, inserted = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(planned) || j < len(changed) {
+		switch {
+		case i < len(planned) && j < len(changed) && planned[i] == changed[j]:
+			flush()
+			result = append(result, PathStation{Name: planned[i], Status: PathStationServed})
+			i++
+			j++
+		case j == len(changed) || (i < len(planned) && common[i+1][j] >= common[i][j+1]):
+			cancelled = append(cancelled, planned[i])
+			i++
+		default:
+			inserted = append(inserted, changed[j])
+			j++
+		}
+	}
+	flush()
+	return result
+}
+
+// Path compares the planned and changed path of the event. Without a changed
+// path all planned stations are served, if the event is cancelled none are.
+func (e *Event) Path() []PathStation {
+	if e == nil {
+		return nil
+	}
+	switch {
+	case e.IsCancelled():
+		return ComparePath(e.PlannedPath, nil)
+	case len(e.ChangedPath) == 0:
+		return ComparePath(e.PlannedPath, e.PlannedPath)
+	default:
+		return ComparePath(e.PlannedPath, e.ChangedPath)
+	}
+}
+
+func (c *ApiClient) ResolvePath(path []PathStation) ([]PathStation, error) {
+	return c.ResolvePathContext(context.Background(), path)
+}
+
+// ResolvePathContext returns a copy of path with the EVA ids of all stations
+// which can be found by name. If any station was resolved from cache after an
+// upstream failure, the path is returned together with the oldest
+// *StaleError.
+func (c *ApiClient) ResolvePathContext(ctx context.Context, path []PathStation) ([]PathStation, error) {
+	var stale staleResults
+	result := make([]PathStation, len(path))
+	copy(result, path)
+	for i := range result {
+		if result[i].EvaId != 0 {
+			continue
+		}
+		evaId, err := c.resolveStation(ctx, result[i].Name)
+		if err = stale.add(err); err != nil {
+			return nil, err
+		}
+		result[i].EvaId = evaId
+	}
+	return result, stale.err()
+}
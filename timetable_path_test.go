@@ -0,0 +1,113 @@
+package bahn
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestComparePath(t *testing.T) {
+	cases := []struct {
+		planned  []string
+		changed  []string
+		expected []PathStation
+	}{
+		{
+			[]string{"A", "B", "C"},
+			[]string{"A", "B", "C"},
+			[]PathStation{{Name: "A", Status: PathStationServed}, {Name: "B", Status: PathStationServed}, {Name: "C", Status: PathStationServed}},
+		},
+		{
+			[]string{"A", "B", "C"},
+			[]string{"A", "C", "D"},
+			[]PathStation{{Name: "A", Status: PathStationServed}, {Name: "B", Status: PathStationCancelled}, {Name: "C", Status: PathStationServed}, {Name: "D", Status: PathStationAdded}},
+		},
+		{
+			[]string{"A", "B", "C", "D"},
+			[]string{"A", "X", "Y", "D"},
+			[]PathStation{{Name: "A", Status: PathStationServed}, {Name: "B", Status: PathStationCancelled}, {Name: "C", Status: PathStationCancelled}, {Name: "X", Status: PathStationDiverted}, {Name: "Y", Status: PathStationDiverted}, {Name: "D", Status: PathStationServed}},
+		},
+		{
+			[]string{"A", "B"},
+			[]string{""},
+			[]PathStation{{Name: "A", Status: PathStationCancelled}, {Name: "B", Status: PathStationCancelled}},
+		},
+	}
+
+	for _, testCase := range cases {
+		path := ComparePath(testCase.planned, testCase.changed)
+		if len(path) != len(testCase.expected) {
+			t.Errorf("%v -> %v: expected %v, got %v", testCase.planned, testCase.changed, testCase.expected, path)
+			continue
+		}
+		for i := range path {
+			if path[i] != testCase.expected[i] {
+				t.Errorf("%v -> %v: expected %v, got %v", testCase.planned, testCase.changed, testCase.expected, path)
+				break
+			}
+		}
+	}
+}
+
+func TestEventPath(t *testing.T) {
+	realtime, err := TimetableFromBytes(realtimeData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diverted := findStop(t, realtime, "-1780366104452543204-1904240829-3")
+	cancelled := 0
+	for _, station := range diverted.Departure.Path() {
+		if station.Status == PathStationCancelled {
+			cancelled++
+			if station.Name != "Heidelberg Hbf" {
+				t.Errorf("unexpected cancelled station %s", station.Name)
+			}
+		} else if station.Status != PathStationServed {
+			t.Errorf("unexpected status %s for %s", station.Status, station.Name)
+		}
+	}
+	if cancelled != 1 {
+		t.Errorf("expected one cancelled station, got %d", cancelled)
+	}
+
+	var available int32 = 1
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.LoadInt32(&available) == 0 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if request.URL.Path == "/timetable/station/Hamburg-Harburg" {
+			_, _ = writer.Write([]byte(`<stations><station name="Hamburg-Harburg" eva="8000147"/></stations>`))
+			return
+		}
+		// fuzzy matches of the search must not be used
+		_, _ = writer.Write([]byte(`<stations><station name="Harburg Rathaus" eva="1"/><station name="Harburg Süd" eva="2"/></stations>`))
+	})
+	defer closeServer()
+
+	client.Caches = []CacheBackend{NewMemoryCache(0, 0)}
+	client.Policies = map[Endpoint]EndpointPolicy{
+		EndpointStation: {TTL: time.Nanosecond, StaleIfError: time.Hour},
+	}
+
+	path, err := client.ResolvePath(diverted.Departure.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path[0].Name != "Hamburg-Harburg" || path[0].EvaId != 8000147 || path[1].EvaId != 0 {
+		t.Errorf("unexpected resolved path %v", path[:2])
+	}
+
+	atomic.StoreInt32(&available, 0)
+	stale, err := client.ResolvePath(diverted.Departure.Path())
+	var staleError *StaleError
+	if !errors.As(err, &staleError) {
+		t.Fatalf("expected stale path, got %v", err)
+	}
+	if len(stale) != len(path) || stale[0].EvaId != 8000147 {
+		t.Errorf("expected cached path, got %v", stale)
+	}
+}
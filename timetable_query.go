@@ -0,0 +1,178 @@
+package bahn
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TimetableQuery describes a window of a station's timetable, starting at
+// Start and either spanning Duration or containing the next Count departures.
+// If both are set, whichever limit is reached first applies. With Realtime
+// set, the change feeds are merged into the planned stops. Results partly
+// served from cache after an upstream failure are returned together with the
+// oldest *StaleError.
+type TimetableQuery struct {
+	EvaId    int64
+	Start    time.Time
+	Duration time.Duration
+	Count    int
+	Realtime bool
+}
+
+// Count queries look ahead in batches of timetableQueryBatch until enough
+// departures are found, but at most timetableQueryLookahead past Start.
+const timetableQueryBatch = 3 * time.Hour
+const timetableQueryLookahead = 24 * time.Hour
+
+func (c *ApiClient) QueryTimetable(query TimetableQuery) (Timetable, error) {
+	return c.QueryTimetableContext(context.Background(), query)
+}
+
+func (c *ApiClient) QueryTimetableContext(ctx context.Context, query TimetableQuery) (Timetable, error) {
+	if query.Duration <= 0 && query.Count <= 0 {
+		return Timetable{}, ErrInvalidQuery
+	}
+
+	end := query.Start.Add(query.Duration)
+	if query.Duration <= 0 {
+		end = query.Start.Add(timetableQueryLookahead)
+	}
+
+	result := Timetable{EvaId: query.EvaId}
+	seen := make(map[string]bool)
+	var stale staleResults
+	for from := query.Start; from.Before(end); from = from.Add(timetableQueryBatch) {
+		to := from.Add(timetableQueryBatch)
+		if query.Count <= 0 || to.After(end) {
+			to = end
+		}
+
+		var board Timetable
+		var err error
+		if query.Realtime {
+			board, err = c.mergedBoard(ctx, query.EvaId, from, to)
+		} else {
+			board, err = c.timetableHours(ctx, query.EvaId, from, to)
+			board.Stops = filterStops(board.Stops, from, to)
+		}
+		if err = stale.add(err); err != nil {
+			return Timetable{}, err
+		}
+
+		if result.Station == "" {
+			result.Station = board.Station
+		}
+		result.Messages = mergeMessages(result.Messages, board.Messages)
+		for _, stop := range board.Stops {
+			if !seen[stop.StopId] {
+				seen[stop.StopId] = true
+				result.Stops = append(result.Stops, stop)
+			}
+		}
+
+		if query.Count > 0 && countDepartures(result.Stops, query.Start) >= query.Count {
+			break
+		}
+	}
+
+	sortStops(result.Stops)
+	if query.Count > 0 {
+		result.Stops = limitDepartures(result.Stops, query.Start, query.Count)
+	}
+	return result, stale.err()
+}
+
+// timetableHours concurrently loads all plan hours overlapping [from, to).
+// Hours are identified by their local wall clock, so the repeated hour at the
+// end of daylight saving time is only loaded once. Hours served from cache
+// after an upstream failure are kept and the oldest *StaleError is returned.
+func (c *ApiClient) timetableHours(ctx context.Context, evaId int64, from time.Time, to time.Time) (Timetable, error) {
+	var hours []time.Time
+	seen := make(map[string]bool)
+	for hour := from.In(Location).Truncate(time.Hour); hour.Before(to); hour = hour.Add(time.Hour) {
+		key := hour.In(Location).Format(timetableHour)
+		if !seen[key] {
+			seen[key] = true
+			hours = append(hours, hour)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	timetables := make([]Timetable, len(hours))
+	errs := make([]error, len(hours))
+	var wait sync.WaitGroup
+	for i, hour := range hours {
+		wait.Add(1)
+		go func(i int, hour time.Time) {
+			defer wait.Done()
+			timetables[i], errs[i] = c.TimetableContext(ctx, evaId, hour)
+			var staleError *StaleError
+			if errs[i] != nil && !errors.As(errs[i], &staleError) {
+				cancel()
+			}
+		}(i, hour)
+	}
+	wait.Wait()
+
+	var stale staleResults
+	for i := range hours {
+		if errs[i] = stale.add(errs[i]); errs[i] != nil && !errors.Is(errs[i], context.Canceled) {
+			return Timetable{}, errs[i]
+		}
+	}
+
+	var result Timetable
+	for i := range hours {
+		if errs[i] != nil {
+			return Timetable{}, errs[i]
+		}
+		result = appendTimetable(result, timetables[i])
+	}
+	return result, stale.err()
+}
+
+func sortStops(stops []TimetableStop) {
+	sort.SliceStable(stops, func(i, j int) bool {
+		a, b := stops[i].EffectiveTime(), stops[j].EffectiveTime()
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+}
+
+func isDeparture(stop TimetableStop, start time.Time) bool {
+	if stop.Departure == nil {
+		return false
+	}
+	eventTime := stop.Departure.EffectiveTime()
+	return eventTime != nil && !eventTime.Before(start)
+}
+
+func countDepartures(stops []TimetableStop, start time.Time) int {
+	count := 0
+	for _, stop := range stops {
+		if isDeparture(stop, start) {
+			count++
+		}
+	}
+	return count
+}
+
+// limitDepartures cuts sorted stops off after the count-th departure.
+func limitDepartures(stops []TimetableStop, start time.Time, count int) []TimetableStop {
+	for i, stop := range stops {
+		if isDeparture(stop, start) {
+			count--
+			if count == 0 {
+				return stops[:i+1]
+			}
+		}
+	}
+	return stops
+}
@@ -0,0 +1,105 @@
+package bahn
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueryTimetable(t *testing.T) {
+	responses := map[string]string{
+		"/timetable/plan/8002549/190424/23": `<timetable station="Hamburg Hbf">
+  <s id="1-1904242300-2"><tl f="N" t="p" o="800201" c="RE" n="1"/><dp pt="1904242350" pp="11"/></s>
+  <s id="2-1904242300-2"><tl f="N" t="p" o="800201" c="RE" n="2"/><dp pt="1904242310" pp="12"/></s>
+  <s id="3-1904242300-2"><tl f="N" t="p" o="800201" c="RE" n="3"/><ar pt="1904242340" pp="12"/></s>
+</timetable>`,
+		"/timetable/plan/8002549/190425/00": `<timetable station="Hamburg Hbf">
+  <s id="4-1904250000-2"><tl f="N" t="p" o="800201" c="RE" n="4"/><dp pt="1904250020" pp="11"/></s>
+  <s id="5-1904250000-2"><tl f="N" t="p" o="800201" c="RE" n="5"/><dp pt="1904250005" pp="11"/></s>
+</timetable>`,
+	}
+	var requests int32
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		response, ok := responses[request.URL.Path]
+		if !ok && strings.HasPrefix(request.URL.Path, "/timetable/plan/") {
+			response = `<timetable station="Hamburg Hbf"/>`
+		}
+		_, _ = writer.Write([]byte(response))
+	})
+	defer closeServer()
+
+	start := time.Date(2019, 4, 24, 23, 30, 0, 0, Location)
+	timetable, err := client.QueryTimetable(TimetableQuery{EvaId: 8002549, Start: start, Duration: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStopIds(t, timetable, "3-1904242300-2", "1-1904242300-2", "5-1904250000-2", "4-1904250000-2")
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+
+	timetable, err = client.QueryTimetable(TimetableQuery{EvaId: 8002549, Start: start, Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStopIds(t, timetable, "3-1904242300-2", "1-1904242300-2", "5-1904250000-2")
+
+	if _, err = client.QueryTimetable(TimetableQuery{EvaId: 8002549, Start: start}); err != ErrInvalidQuery {
+		t.Errorf("expected invalid query, got %v", err)
+	}
+}
+
+func TestQueryTimetableStale(t *testing.T) {
+	responses := map[string]string{
+		"/timetable/plan/8002549/190424/15": `<timetable station="Hamburg Hbf">
+  <s id="1-1904241500-2"><tl f="N" t="p" o="800201" c="RE" n="1"/><dp pt="1904241510" pp="11"/></s>
+</timetable>`,
+		"/timetable/plan/8002549/190424/16": `<timetable station="Hamburg Hbf">
+  <s id="2-1904241600-2"><tl f="N" t="p" o="800201" c="RE" n="2"/><dp pt="1904241610" pp="11"/></s>
+</timetable>`,
+	}
+	var available int32 = 1
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.LoadInt32(&available) == 0 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = writer.Write([]byte(responses[request.URL.Path]))
+	})
+	defer closeServer()
+
+	client.Caches = []CacheBackend{NewMemoryCache(0, 0)}
+	client.Policies = map[Endpoint]EndpointPolicy{
+		EndpointTimetable: {TTL: time.Nanosecond, StaleIfError: time.Hour},
+	}
+
+	query := TimetableQuery{EvaId: 8002549, Start: time.Date(2019, 4, 24, 15, 0, 0, 0, Location), Duration: 2 * time.Hour}
+	if _, err := client.QueryTimetable(query); err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&available, 0)
+	timetable, err := client.QueryTimetable(query)
+	var staleError *StaleError
+	if !errors.As(err, &staleError) || !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("expected stale result, got %v", err)
+	}
+	assertStopIds(t, timetable, "1-1904241500-2", "2-1904241600-2")
+}
+
+func assertStopIds(t *testing.T, timetable Timetable, stopIds ...string) {
+	t.Helper()
+	if len(timetable.Stops) != len(stopIds) {
+		t.Errorf("expected %d stops, got %d", len(stopIds), len(timetable.Stops))
+		return
+	}
+	for i, stopId := range stopIds {
+		if timetable.Stops[i].StopId != stopId {
+			t.Errorf("expected stop %d to be %s, got %s", i, stopId, timetable.Stops[i].StopId)
+		}
+	}
+}
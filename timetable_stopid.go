@@ -0,0 +1,73 @@
+package bahn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StopId is a decoded IRIS stop id such as
+// "-7676591997781413630-1904241307-19": the daily trip id, the time the trip
+// starts at its first station and the index of the stop within the trip.
+type StopId struct {
+	TripId    int64
+	TripStart time.Time
+	Index     int
+}
+
+func ParseStopId(value string) (StopId, error) {
+	invalid := func(reason string) (StopId, error) {
+		return StopId{}, fmt.Errorf("%w '%s': %s", ErrInvalidStopId, value, reason)
+	}
+
+	indexSeparator := strings.LastIndexByte(value, '-')
+	if indexSeparator <= 0 {
+		return invalid("missing stop index")
+	}
+	startSeparator := strings.LastIndexByte(value[:indexSeparator], '-')
+	if startSeparator <= 0 {
+		return invalid("missing trip start")
+	}
+
+	tripId, err := strconv.ParseInt(value[:startSeparator], 10, 64)
+	if err != nil {
+		return invalid("malformed trip id")
+	}
+	start := value[startSeparator+1 : indexSeparator]
+	if len(start) != len(TimeLayoutShort) {
+		return invalid("malformed trip start")
+	}
+	tripStart, err := parseLocal(TimeLayoutShort, start)
+	if err != nil {
+		return invalid("malformed trip start")
+	}
+	index, err := strconv.Atoi(value[indexSeparator+1:])
+	if err != nil || index < 0 {
+		return invalid("malformed stop index")
+	}
+
+	return StopId{
+		TripId:    tripId,
+		TripStart: tripStart,
+		Index:     index,
+	}, nil
+}
+
+// TripKey identifies the trip on its day of operation, it is shared by all
+// stops of the trip and matches the ids referenced by Event.Wings.
+func (s StopId) TripKey() string {
+	return fmt.Sprintf("%d-%s", s.TripId, s.TripStart.In(Location).Format(TimeLayoutShort))
+}
+
+func (s StopId) SameTrip(other StopId) bool {
+	return s.TripId == other.TripId && s.TripStart.Equal(other.TripStart)
+}
+
+func (s StopId) String() string {
+	return fmt.Sprintf("%s-%d", s.TripKey(), s.Index)
+}
+
+func (s *TimetableStop) ParseStopId() (StopId, error) {
+	return ParseStopId(s.StopId)
+}
@@ -0,0 +1,56 @@
+package bahn
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseStopId(t *testing.T) {
+	stopId, err := ParseStopId("-7676591997781413630-1904241307-19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopId.TripId != -7676591997781413630 || stopId.Index != 19 {
+		t.Errorf("unexpected stop id %+v", stopId)
+	}
+	if expected := time.Date(2019, 4, 24, 13, 7, 0, 0, Location); !stopId.TripStart.Equal(expected) {
+		t.Errorf("expected trip start %s, got %s", expected, stopId.TripStart)
+	}
+	if stopId.String() != "-7676591997781413630-1904241307-19" || stopId.TripKey() != "-7676591997781413630-1904241307" {
+		t.Errorf("unexpected formatting %s", stopId)
+	}
+
+	other, err := ParseStopId("-7676591997781413630-1904241307-3")
+	if err != nil || !stopId.SameTrip(other) {
+		t.Errorf("expected stops of the same trip, got %+v %v", other, err)
+	}
+
+	for _, value := range []string{
+		"",
+		"8145855560011432677",
+		"8145855560011432677-1904251234",
+		"-1904251234-1",
+		"abc-1904251234-1",
+		"8145855560011432677-19042512-1",
+		"8145855560011432677-1913251234-1",
+		"8145855560011432677-1904251234-x",
+		"8145855560011432677-1904251234--1",
+	} {
+		if _, err := ParseStopId(value); !errors.Is(err, ErrInvalidStopId) {
+			t.Errorf("expected '%s' to be invalid, got %v", value, err)
+		}
+	}
+
+	for _, data := range [][]byte{timetableData, realtimeData} {
+		timetable, err := TimetableFromBytes(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stop := range timetable.Stops {
+			if _, err := stop.ParseStopId(); err != nil {
+				t.Errorf("expected fixture stop id to parse, got %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package bahn
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// The trip builder looks for a trip at the next station on its path within
+// tripSearchHours plan hours after (or before) the previous stop.
+const tripSearchHours = 3
+
+func (c *ApiClient) Trip(evaId int64, stop TimetableStop) (Trip, error) {
+	return c.TripContext(context.Background(), evaId, stop)
+}
+
+// TripContext reconstructs the whole run of the trip stop belongs to, which
+// was taken from the timetable of station evaId. The stations on its path are
+// resolved by name and their plan hours are searched for stops of the same
+// trip, with the full changes of each station applied. Stations which cannot
+// be resolved or where the trip cannot be found are included without Stop.
+// If any lookup was served from cache after an upstream failure, the trip is
+// returned together with the oldest *StaleError.
+func (c *ApiClient) TripContext(ctx context.Context, evaId int64, stop TimetableStop) (Trip, error) {
+	stopId, err := stop.ParseStopId()
+	if err != nil {
+		return Trip{}, err
+	}
+
+	var stale staleResults
+	current := TripStop{
+		EvaId: evaId,
+		Stop:  &stop,
+	}
+	stations, err := c.StationContext(ctx, evaId)
+	if err = stale.add(err); err != nil && !errors.Is(err, ErrNotFound) {
+		return Trip{}, err
+	} else if len(stations) > 0 {
+		current.Station = stations[0].StationName
+	}
+	if current.Stop, err = c.tripRealtime(ctx, &stale, evaId, stop); err != nil {
+		return Trip{}, err
+	}
+
+	trip := Trip{
+		TripId:    stopId.TripId,
+		TripStart: stopId.TripStart,
+		TripLabel: stop.TripLabel,
+	}
+
+	before := tripPath(current.Stop.Arrival)
+	previous := make([]TripStop, len(before))
+	searchFrom := tripSearchTime(stop.Arrival, stopId.TripStart)
+	for i := len(before) - 1; i >= 0; i-- {
+		if previous[i], err = c.tripStop(ctx, &stale, before[i], stopId, searchFrom, -1); err != nil {
+			return Trip{}, err
+		}
+		if previous[i].Stop != nil {
+			searchFrom = tripSearchTime(previous[i].Stop.Departure, searchFrom)
+		}
+	}
+	trip.Stops = append(trip.Stops, previous...)
+	trip.Stops = append(trip.Stops, current)
+
+	searchFrom = tripSearchTime(stop.Departure, stopId.TripStart)
+	for _, station := range tripPath(current.Stop.Departure) {
+		next, err := c.tripStop(ctx, &stale, station, stopId, searchFrom, 1)
+		if err != nil {
+			return Trip{}, err
+		}
+		if next.Stop != nil {
+			searchFrom = tripSearchTime(next.Stop.Arrival, searchFrom)
+		}
+		trip.Stops = append(trip.Stops, next)
+	}
+	return trip, stale.err()
+}
+
+// tripStop searches the plan hours of station, starting at the hour of
+// searchFrom and moving in the given direction, for the stop of the trip.
+func (c *ApiClient) tripStop(ctx context.Context, stale *staleResults, station string, stopId StopId, searchFrom time.Time, direction int) (TripStop, error) {
+	result := TripStop{Station: station}
+
+	evaId, err := c.resolveStation(ctx, station)
+	if err = stale.add(err); err != nil || evaId == 0 {
+		return result, err
+	}
+	result.EvaId = evaId
+
+	hour := searchFrom.In(Location).Truncate(time.Hour)
+	for i := 0; i < tripSearchHours; i++ {
+		if direction < 0 && hour.Before(stopId.TripStart.Truncate(time.Hour)) {
+			break
+		}
+		timetable, err := c.TimetableContext(ctx, evaId, hour)
+		if err = stale.add(err); err != nil && !errors.Is(err, ErrNotFound) {
+			return result, err
+		}
+		if stop := findTripStop(timetable, stopId); stop != nil {
+			result.Stop, err = c.tripRealtime(ctx, stale, evaId, *stop)
+			return result, err
+		}
+		hour = hour.Add(time.Duration(direction) * time.Hour)
+	}
+
+	c.logger().Log(LogLevelDebug, "Trip not found at station", LogField{"station", station}, LogField{"eva_id", evaId}, LogField{"trip", stopId.TripKey()})
+	return result, nil
+}
+
+// tripRealtime applies the full changes of station evaId to stop.
+func (c *ApiClient) tripRealtime(ctx context.Context, stale *staleResults, evaId int64, stop TimetableStop) (*TimetableStop, error) {
+	changes, err := c.RealtimeAllContext(ctx, evaId, c.now())
+	if err = stale.add(err); err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	for _, change := range changes.Stops {
+		if change.StopId == stop.StopId {
+			stop = mergeStop(stop, change)
+			break
+		}
+	}
+	return &stop, nil
+}
+
+// resolveStation returns the EVA id of the station with the given name, or 0
+// if the search neither finds an exact match nor a single station. A search
+// served from cache is still used and returned with its *StaleError.
+func (c *ApiClient) resolveStation(ctx context.Context, name string) (int64, error) {
+	stations, err := c.stationByName(ctx, name)
+	var staleError *StaleError
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	} else if err != nil && !errors.As(err, &staleError) {
+		return 0, err
+	}
+
+	var match *Station
+	for i := range stations {
+		if stations[i].StationName == name {
+			match = &stations[i]
+			break
+		}
+	}
+	if match == nil && len(stations) == 1 {
+		match = &stations[0]
+	}
+	if match == nil {
+		return 0, err
+	}
+
+	evaId, parseErr := strconv.ParseInt(match.EvaId, 10, 64)
+	if parseErr != nil {
+		return 0, parseErr
+	}
+	return evaId, err
+}
+
+func findTripStop(timetable Timetable, stopId StopId) *TimetableStop {
+	for i := range timetable.Stops {
+		if other, err := timetable.Stops[i].ParseStopId(); err == nil && other.SameTrip(stopId) {
+			return &timetable.Stops[i]
+		}
+	}
+	return nil
+}
+
+// tripPath lists all stations of the event's path, including cancelled and
+// added ones.
+func tripPath(event *Event) []string {
+	var result []string
+	for _, station := range event.Path() {
+		result = append(result, station.Name)
+	}
+	return result
+}
+
+func tripSearchTime(event *Event, fallback time.Time) time.Time {
+	if event != nil && event.PlannedTime != nil {
+		return *event.PlannedTime
+	}
+	if eventTime := event.EffectiveTime(); eventTime != nil {
+		return *eventTime
+	}
+	return fallback
+}
@@ -0,0 +1,104 @@
+package bahn
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTrip(t *testing.T) {
+	responses := map[string]string{
+		"/timetable/station/Altona":   `<stations><station name="Altona" eva="1"/></stations>`,
+		"/timetable/station/2":        `<stations><station name="Dammtor" eva="2"/></stations>`,
+		"/timetable/station/Harburg":  `<stations><station name="Harburg Rathaus" eva="4"/><station name="Harburg" eva="3"/></stations>`,
+		"/timetable/plan/1/190424/14": `<timetable station="Altona"><s id="111-1904241400-1"><dp pt="1904241400" pp="1" ppth="Dammtor|Harburg|Ausland"/></s></timetable>`,
+		"/timetable/plan/3/190424/16": `<timetable station="Harburg"><s id="111-1904241400-3"><ar pt="1904241610" pp="3" ppth="Altona|Dammtor"/></s></timetable>`,
+		"/timetable/fchg/2":           `<timetable station="Dammtor" eva="2"><s id="111-1904241400-2" eva="2"><dp cpth="Harburg|Stade|Ausland"/></s></timetable>`,
+		"/timetable/fchg/3":           `<timetable station="Harburg" eva="3"><s id="111-1904241400-3" eva="3"><ar ct="1904241620" cp="4"/></s></timetable>`,
+	}
+	var available int32 = 1
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.LoadInt32(&available) == 0 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		response, ok := responses[request.URL.Path]
+		switch {
+		case ok:
+		case strings.HasPrefix(request.URL.Path, "/timetable/station/"):
+			response = `<stations/>`
+		default:
+			response = `<timetable/>`
+		}
+		_, _ = writer.Write([]byte(response))
+	})
+	defer closeServer()
+
+	client.Caches = []CacheBackend{NewMemoryCache(0, 0)}
+	client.Policies = map[Endpoint]EndpointPolicy{
+		EndpointStation:     {TTL: time.Nanosecond, StaleIfError: time.Hour},
+		EndpointTimetable:   {TTL: time.Nanosecond, StaleIfError: time.Hour},
+		EndpointRealtimeAll: {TTL: time.Nanosecond, StaleIfError: time.Hour},
+	}
+
+	stops, err := TimetableFromBytes([]byte(`<timetable station="Dammtor"><s id="111-1904241400-2">
+  <tl f="F" t="p" o="80" c="ICE" n="1"/>
+  <ar pt="1904241450" pp="2" ppth="Altona"/>
+  <dp pt="1904241452" pp="2" ppth="Harburg|Ausland"/>
+</s></timetable>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trip, err := client.Trip(2, stops.Stops[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trip.TripId != 111 || trip.TripLabel.TripNumber != "1" || len(trip.Stops) != 5 {
+		t.Fatalf("unexpected trip %+v", trip)
+	}
+
+	expected := []struct {
+		station string
+		evaId   int64
+		stopId  string
+	}{
+		{"Altona", 1, "111-1904241400-1"},
+		{"Dammtor", 2, "111-1904241400-2"},
+		{"Harburg", 3, "111-1904241400-3"},
+		{"Stade", 0, ""},
+		{"Ausland", 0, ""},
+	}
+	for i, expectedStop := range expected {
+		stop := trip.Stops[i]
+		if stop.Station != expectedStop.station || stop.EvaId != expectedStop.evaId {
+			t.Errorf("expected stop %d at %s (%d), got %s (%d)", i, expectedStop.station, expectedStop.evaId, stop.Station, stop.EvaId)
+		}
+		if (stop.Stop == nil && expectedStop.stopId != "") || (stop.Stop != nil && stop.Stop.StopId != expectedStop.stopId) {
+			t.Errorf("expected stop %d to be %s, got %+v", i, expectedStop.stopId, stop.Stop)
+		}
+	}
+
+	harburg := trip.Stops[2].Stop
+	if harburg.Delay() != 10*time.Minute || harburg.EffectivePlatform() != "4" {
+		t.Errorf("expected realtime overlay, got %+v", harburg.Arrival)
+	}
+
+	atomic.StoreInt32(&available, 0)
+	stale, err := client.Trip(2, stops.Stops[0])
+	var staleError *StaleError
+	if !errors.As(err, &staleError) || !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("expected stale trip, got %v", err)
+	}
+	if len(stale.Stops) != len(trip.Stops) {
+		t.Fatalf("expected cached trip, got %+v", stale)
+	}
+	for i := range trip.Stops {
+		if stale.Stops[i].EvaId != trip.Stops[i].EvaId || (stale.Stops[i].Stop == nil) != (trip.Stops[i].Stop == nil) {
+			t.Errorf("expected cached stop %d %+v, got %+v", i, trip.Stops[i], stale.Stops[i])
+		}
+	}
+}
@@ -1,12 +1,95 @@
 package bahn
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"encoding/xml"
 	"strings"
 	"time"
 )
 
+// Location is the time zone all upstream timestamps without an explicit
+// offset are interpreted in. It is loaded from the system time zone database,
+// applications running without one can import time/tzdata or set Location
+// during initialization, otherwise the current CET/CEST rule is used for all
+// dates.
+var Location = loadLocation("Europe/Berlin")
+
+func loadLocation(name string) *time.Location {
+	if location, err := time.LoadLocation(name); err == nil {
+		return location
+	}
+	return centralEuropeanTime(name)
+}
+
+// centralEuropeanTime builds a location following the CET/CEST rule in effect
+// since 1996 by wrapping it as POSIX TZ string in an otherwise empty TZif file.
+func centralEuropeanTime(name string) *time.Location {
+	const abbreviations = "CET\x00CEST\x00"
+	header := func(types int, chars int) []byte {
+		data := []byte("TZif2")
+		data = append(data, make([]byte, 15)...)
+		counts := make([]byte, 24)
+		binary.BigEndian.PutUint32(counts[16:], uint32(types))
+		binary.BigEndian.PutUint32(counts[20:], uint32(chars))
+		return append(data, counts...)
+	}
+
+	// the version 1 block is skipped by the reader, but has to be present
+	data := header(1, 1)
+	data = append(data, 0, 0, 0, 0, 0, 0, 0)
+	data = append(data, header(2, len(abbreviations))...)
+	// UTC offset in seconds, daylight saving time, abbreviation index
+	data = append(data, 0, 0, 0x0e, 0x10, 0, 0)
+	data = append(data, 0, 0, 0x1c, 0x20, 1, 4)
+	data = append(data, abbreviations...)
+	data = append(data, "\nCET-1CEST,M3.5.0,M10.5.0/3\n"...)
+
+	location, err := time.LoadLocationFromTZData(name, data)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
+
+func parseLocal(layout string, value string) (time.Time, error) {
+	wall, err := time.Parse(layout, value)
+	if err != nil {
+		return wall, err
+	}
+	return inLocation(wall, Location), nil
+}
+
+// inLocation interprets the wall clock of the given time in location. Wall
+// clock times which occur twice when clocks are turned back resolve to the
+// earlier instant, times skipped when clocks are turned forward are shifted
+// forward by the length of the gap.
+func inLocation(wall time.Time, location *time.Location) time.Time {
+	year, month, day := wall.Date()
+	hour, minute, second := wall.Clock()
+	naive := time.Date(year, month, day, hour, minute, second, wall.Nanosecond(), time.UTC)
+
+	_, offsetBefore := naive.Add(-12 * time.Hour).In(location).Zone()
+	_, offsetAfter := naive.Add(12 * time.Hour).In(location).Zone()
+
+	var result time.Time
+	for _, offset := range []int{offsetBefore, offsetAfter} {
+		candidate := naive.Add(-time.Duration(offset) * time.Second).In(location)
+		if candidate.Format(wallClockLayout) != naive.Format(wallClockLayout) {
+			continue
+		}
+		if result.IsZero() || candidate.Before(result) {
+			result = candidate
+		}
+	}
+	if result.IsZero() {
+		result = naive.Add(-time.Duration(offsetBefore) * time.Second).In(location)
+	}
+	return result
+}
+
+const wallClockLayout = "2006-01-02T15:04:05.999999999"
+
 type bahnStringList []string
 
 func (s *bahnStringList) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
@@ -45,14 +128,14 @@ func (t *timeLong) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
 	} else {
 		return xml.Attr{
 			Name:  name,
-			Value: t.Format(TimeLayoutLong),
+			Value: t.In(Location).Format(TimeLayoutLong),
 		}, nil
 	}
 }
 
 func (t *timeLong) UnmarshalXMLAttr(attr xml.Attr) error {
 	if attr.Value != "" {
-		value, err := time.Parse(TimeLayoutLong, attr.Value)
+		value, err := parseLocal(TimeLayoutLong, attr.Value)
 		if err != nil {
 			return err
 		}
@@ -81,14 +164,14 @@ func (t *timeShort) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
 	} else {
 		return xml.Attr{
 			Name:  name,
-			Value: t.Format(TimeLayoutShort),
+			Value: t.In(Location).Format(TimeLayoutShort),
 		}, nil
 	}
 }
 
 func (t *timeShort) UnmarshalXMLAttr(attr xml.Attr) error {
 	if attr.Value != "" {
-		value, err := time.Parse(TimeLayoutShort, attr.Value)
+		value, err := parseLocal(TimeLayoutShort, attr.Value)
 		if err != nil {
 			return err
 		}
@@ -117,14 +200,14 @@ func (t *timeMediumShort) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
 	} else {
 		return xml.Attr{
 			Name:  name,
-			Value: t.Format(TimeLayoutMediumShort),
+			Value: t.In(Location).Format(TimeLayoutMediumShort),
 		}, nil
 	}
 }
 
 func (t *timeMediumShort) UnmarshalXMLAttr(attr xml.Attr) error {
 	if attr.Value != "" {
-		value, err := time.Parse(TimeLayoutMediumShort, attr.Value)
+		value, err := parseLocal(TimeLayoutMediumShort, attr.Value)
 		if err != nil {
 			return err
 		}
@@ -153,14 +236,14 @@ func (t *timeMedium) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
 	} else {
 		return xml.Attr{
 			Name:  name,
-			Value: t.Format(TimeLayoutMedium),
+			Value: t.In(Location).Format(TimeLayoutMedium),
 		}, nil
 	}
 }
 
 func (t *timeMedium) UnmarshalXMLAttr(attr xml.Attr) error {
 	if attr.Value != "" {
-		value, err := time.Parse(TimeLayoutMedium, attr.Value)
+		value, err := parseLocal(TimeLayoutMedium, attr.Value)
 		if err != nil {
 			return err
 		}
@@ -174,7 +257,7 @@ func (t *timeMedium) MarshalJSON() ([]byte, error) {
 	if t == nil || t.IsZero() {
 		text = ""
 	} else {
-		text = t.Format(TimeLayoutMedium)
+		text = t.In(Location).Format(TimeLayoutMedium)
 	}
 	return json.Marshal(&text)
 }
@@ -189,7 +272,7 @@ func (t *timeMedium) UnmarshalJSON(data []byte) error {
 
 	if text != "" {
 		var value time.Time
-		if value, err = time.Parse(TimeLayoutMedium, text); err != nil {
+		if value, err = parseLocal(TimeLayoutMedium, text); err != nil {
 			return err
 		}
 		t.Time = value
@@ -217,14 +300,14 @@ func (t *bahnDate) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
 	} else {
 		return xml.Attr{
 			Name:  name,
-			Value: t.Format(DateLayoutLong),
+			Value: t.In(Location).Format(DateLayoutLong),
 		}, nil
 	}
 }
 
 func (t *bahnDate) UnmarshalXMLAttr(attr xml.Attr) error {
 	if attr.Value != "" {
-		value, err := time.Parse(DateLayoutLong, attr.Value)
+		value, err := parseLocal(DateLayoutLong, attr.Value)
 		if err != nil {
 			return err
 		}
@@ -238,7 +321,7 @@ func (t *bahnDate) MarshalJSON() ([]byte, error) {
 	if t == nil || t.IsZero() {
 		text = ""
 	} else {
-		text = t.Format(DateLayoutLong)
+		text = t.In(Location).Format(DateLayoutLong)
 	}
 	return json.Marshal(&text)
 }
@@ -253,7 +336,7 @@ func (t *bahnDate) UnmarshalJSON(data []byte) error {
 
 	if text != "" {
 		var value time.Time
-		if value, err = time.Parse(DateLayoutLong, text); err != nil {
+		if value, err = parseLocal(DateLayoutLong, text); err != nil {
 			return err
 		}
 		t.Time = value
@@ -0,0 +1,53 @@
+package bahn
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestTimeShortLocation(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"1904241540", time.Date(2019, 4, 24, 13, 40, 0, 0, time.UTC)},
+		{"1901151540", time.Date(2019, 1, 15, 14, 40, 0, 0, time.UTC)},
+		// clocks turned forward at 02:00, 02:30 does not exist
+		{"1903310230", time.Date(2019, 3, 31, 1, 30, 0, 0, time.UTC)},
+		{"1903310330", time.Date(2019, 3, 31, 1, 30, 0, 0, time.UTC)},
+		// clocks turned back at 03:00, 02:30 occurs twice
+		{"1910270230", time.Date(2019, 10, 27, 0, 30, 0, 0, time.UTC)},
+		{"1910270330", time.Date(2019, 10, 27, 2, 30, 0, 0, time.UTC)},
+	}
+
+	for _, testCase := range cases {
+		var value timeShort
+		if err := value.UnmarshalXMLAttr(xml.Attr{Value: testCase.value}); err != nil {
+			t.Fatal(err)
+		}
+		if !value.Equal(testCase.expected) {
+			t.Errorf("%s: expected %s, got %s", testCase.value, testCase.expected, value.UTC())
+		}
+		if value.Location() != Location {
+			t.Errorf("%s: expected location %s, got %s", testCase.value, Location, value.Location())
+		}
+	}
+}
+
+func TestCentralEuropeanTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	fallback := centralEuropeanTime("Europe/Berlin")
+
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	for instant := start; instant.Year() < 2026; instant = instant.Add(30 * time.Minute) {
+		expectedName, expectedOffset := instant.In(berlin).Zone()
+		name, offset := instant.In(fallback).Zone()
+		if name != expectedName || offset != expectedOffset {
+			t.Fatalf("%s: expected %s %d, got %s %d", instant, expectedName, expectedOffset, name, offset)
+		}
+	}
+}
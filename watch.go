@@ -0,0 +1,225 @@
+package bahn
+
+import (
+	"context"
+	"time"
+)
+
+type WatchEventType string
+
+const (
+	WatchEventDelayChanged    WatchEventType = "DELAY_CHANGED"
+	WatchEventPlatformChanged WatchEventType = "PLATFORM_CHANGED"
+	WatchEventCancelled       WatchEventType = "CANCELLED"
+	WatchEventReinstated      WatchEventType = "REINSTATED"
+	WatchEventMessageAdded    WatchEventType = "MESSAGE_ADDED"
+	WatchEventTripAdded       WatchEventType = "TRIP_ADDED"
+)
+
+// WatchEvent describes a change of a stop between two polls. Stop is the
+// current state with all changes seen so far applied, Previous the state
+// before, or nil if the stop has not been seen before. Message is only set
+// for WatchEventMessageAdded.
+type WatchEvent struct {
+	Type     WatchEventType
+	EvaId    int64
+	Stop     TimetableStop
+	Previous *TimetableStop
+	Message  *Message
+}
+
+const DefaultWatchInterval = 30 * time.Second
+
+// The recent changes only cover about the last two minutes, if the previous
+// successful poll of a station is older, the full changes are polled instead.
+// Those are also polled every watchResyncInterval to drop stops which are no
+// longer included in them.
+const watchRecentWindow = 2 * time.Minute
+const watchResyncInterval = 15 * time.Minute
+
+// Watch polls the recent changes of the given stations every interval and
+// emits an event for each change on the returned channel. The state of each
+// station is initialized from its full changes, which do not emit events.
+// Failed polls are logged, the next poll then loads the full changes again
+// and emits the differences. Intervals of more than two minutes exceed what
+// the recent changes cover, so the full changes are polled every time. The
+// channel is closed once ctx is done.
+func (c *ApiClient) Watch(ctx context.Context, evaIds []int64, interval time.Duration) <-chan WatchEvent {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	events := make(chan WatchEvent)
+	watcher := &watcher{
+		client:   c,
+		events:   events,
+		stations: make(map[int64]*watchedStation, len(evaIds)),
+	}
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			for _, evaId := range evaIds {
+				if !watcher.poll(ctx, evaId) {
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return events
+}
+
+type watcher struct {
+	client   *ApiClient
+	events   chan<- WatchEvent
+	stations map[int64]*watchedStation
+}
+
+type watchedStation struct {
+	stops    map[string]TimetableStop
+	polledAt time.Time
+	syncedAt time.Time
+	failed   bool
+}
+
+// poll updates the state of one station and returns false once ctx is done.
+func (w *watcher) poll(ctx context.Context, evaId int64) bool {
+	station := w.stations[evaId]
+	now := w.client.now()
+	full := station == nil || station.failed ||
+		now.Sub(station.polledAt) >= watchRecentWindow ||
+		now.Sub(station.syncedAt) >= watchResyncInterval
+
+	var changes Timetable
+	var err error
+	if full {
+		changes, err = w.client.RealtimeAllContext(ctx, evaId, now)
+	} else {
+		changes, err = w.client.RealtimeRecentContext(ctx, evaId, now)
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		w.client.logger().Log(LogLevelWarn, "Polling changes failed", LogField{"eva_id", evaId}, LogField{"error", err})
+		if station != nil {
+			station.failed = true
+		}
+		return true
+	}
+
+	if station == nil {
+		stops := make(map[string]TimetableStop, len(changes.Stops))
+		for _, stop := range changes.Stops {
+			stops[stop.StopId] = mergeStop(TimetableStop{}, stop)
+		}
+		w.stations[evaId] = &watchedStation{stops: stops, polledAt: now, syncedAt: now}
+		return true
+	}
+
+	previousStops := station.stops
+	station.polledAt = now
+	if full {
+		// the full changes replace the state, dropping stops which are no
+		// longer included
+		station.stops = make(map[string]TimetableStop, len(changes.Stops))
+		station.syncedAt, station.failed = now, false
+	}
+
+	for _, change := range changes.Stops {
+		previous, seen := previousStops[change.StopId]
+		stop := mergeStop(previous, change)
+		if full {
+			stop = mergeStop(TimetableStop{}, change)
+		}
+		station.stops[change.StopId] = stop
+
+		var previousStop *TimetableStop
+		if seen {
+			previousStop = &previous
+		}
+		for _, event := range watchEvents(evaId, previousStop, stop) {
+			select {
+			case w.events <- event:
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func watchEvents(evaId int64, previous *TimetableStop, stop TimetableStop) []WatchEvent {
+	var events []WatchEvent
+	emit := func(eventType WatchEventType, message *Message) {
+		events = append(events, WatchEvent{
+			Type:     eventType,
+			EvaId:    evaId,
+			Stop:     stop,
+			Previous: previous,
+			Message:  message,
+		})
+	}
+
+	var before TimetableStop
+	if previous != nil {
+		before = *previous
+	} else if stop.Arrival.IsAdded() || stop.Departure.IsAdded() {
+		emit(WatchEventTripAdded, nil)
+		return events
+	}
+
+	if !before.IsCancelled() && stop.IsCancelled() {
+		emit(WatchEventCancelled, nil)
+	} else if before.IsCancelled() && !stop.IsCancelled() {
+		emit(WatchEventReinstated, nil)
+	}
+	if !timeEqual(before.EffectiveTime(), stop.EffectiveTime()) {
+		emit(WatchEventDelayChanged, nil)
+	}
+	if before.EffectivePlatform() != stop.EffectivePlatform() {
+		emit(WatchEventPlatformChanged, nil)
+	}
+
+	known := make(map[string]bool)
+	for _, message := range stopMessages(before) {
+		known[message.MessageId] = true
+	}
+	for _, message := range stopMessages(stop) {
+		if !known[message.MessageId] {
+			known[message.MessageId] = true
+			message := message
+			emit(WatchEventMessageAdded, &message)
+		}
+	}
+	return events
+}
+
+// stopMessages returns all messages attached to the stop, its trip label and
+// its events.
+func stopMessages(stop TimetableStop) []Message {
+	var result []Message
+	result = append(result, stop.Messages...)
+	result = append(result, stop.TripLabel.Messages...)
+	for _, event := range []*Event{stop.Arrival, stop.Departure} {
+		if event != nil {
+			result = append(result, event.Messages...)
+		}
+	}
+	return result
+}
+
+func timeEqual(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
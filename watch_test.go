@@ -0,0 +1,165 @@
+package bahn
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	recent := []string{
+		`<timetable station="Hamburg Hbf" eva="8002549">
+  <s id="1-1904241500-5" eva="8002549">
+    <dp ct="1904241545" cp="7"><m id="r2" t="d" c="36" ts="1904241410"/></dp>
+  </s>
+  <s id="2-1904241500-3" eva="8002549"><dp cs="c"/></s>
+</timetable>`,
+		`<timetable station="Hamburg Hbf" eva="8002549">
+  <s id="2-1904241500-3" eva="8002549"><dp cs="p"/></s>
+  <s id="3-1904241500-1" eva="8002549">
+    <tl f="F" t="e" o="80" c="ICE" n="2903"/>
+    <dp pt="1904241550" pp="8" cs="a"/>
+  </s>
+</timetable>`,
+	}
+	var polls int32
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/timetable/fchg/8002549":
+			_, _ = writer.Write([]byte(`<timetable station="Hamburg Hbf" eva="8002549">
+  <s id="1-1904241500-5" eva="8002549">
+    <dp ct="1904241540"><m id="r1" t="d" c="36" ts="1904241400"/></dp>
+  </s>
+  <s id="2-1904241500-3" eva="8002549"><dp ct="1904241510"/></s>
+</timetable>`))
+		case "/timetable/rchg/8002549":
+			if poll := int(atomic.AddInt32(&polls, 1)); poll <= len(recent) {
+				_, _ = writer.Write([]byte(recent[poll-1]))
+			} else {
+				_, _ = writer.Write([]byte(`<timetable station="Hamburg Hbf" eva="8002549"/>`))
+			}
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer closeServer()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events := client.Watch(ctx, []int64{8002549}, 10*time.Millisecond)
+
+	expected := []struct {
+		eventType WatchEventType
+		stopId    string
+	}{
+		{WatchEventDelayChanged, "1-1904241500-5"},
+		{WatchEventPlatformChanged, "1-1904241500-5"},
+		{WatchEventMessageAdded, "1-1904241500-5"},
+		{WatchEventCancelled, "2-1904241500-3"},
+		{WatchEventReinstated, "2-1904241500-3"},
+		{WatchEventTripAdded, "3-1904241500-1"},
+	}
+	for _, expectedEvent := range expected {
+		event, ok := <-events
+		if !ok {
+			t.Fatalf("channel closed early, expected %s", expectedEvent.eventType)
+		}
+		if event.Type != expectedEvent.eventType || event.Stop.StopId != expectedEvent.stopId {
+			t.Errorf("expected %s for %s, got %s for %s", expectedEvent.eventType, expectedEvent.stopId, event.Type, event.Stop.StopId)
+		}
+		if event.Type == WatchEventMessageAdded && (event.Message == nil || event.Message.MessageId != "r2") {
+			t.Errorf("expected message r2, got %+v", event.Message)
+		}
+		if event.Type == WatchEventDelayChanged && event.Previous.Departure.ChangedTime.Minute() != 40 {
+			t.Errorf("unexpected previous state %+v", event.Previous.Departure)
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchResync(t *testing.T) {
+	full := []string{
+		`<timetable station="Hamburg Hbf" eva="8002549">
+  <s id="1-1904241500-5" eva="8002549"><dp ct="1904241540"/></s>
+  <s id="2-1904241500-3" eva="8002549"><dp ct="1904241510"/></s>
+</timetable>`,
+		`<timetable station="Hamburg Hbf" eva="8002549">
+  <s id="1-1904241500-5" eva="8002549"><dp ct="1904241550"/></s>
+</timetable>`,
+		`<timetable station="Hamburg Hbf" eva="8002549">
+  <s id="1-1904241500-5" eva="8002549"><dp ct="1904241550" cp="7"/></s>
+</timetable>`,
+	}
+	recent := []string{
+		"",
+		`<timetable station="Hamburg Hbf" eva="8002549">
+  <s id="1-1904241500-5" eva="8002549"><dp cp="7"/></s>
+</timetable>`,
+	}
+	var fullPolls, recentPolls int32
+	client, closeServer := newTestClient(func(writer http.ResponseWriter, request *http.Request) {
+		var response string
+		switch request.URL.Path {
+		case "/timetable/fchg/8002549":
+			response = full[atomic.AddInt32(&fullPolls, 1)-1]
+		case "/timetable/rchg/8002549":
+			response = recent[atomic.AddInt32(&recentPolls, 1)-1]
+		}
+		if response == "" {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = writer.Write([]byte(response))
+	})
+	defer closeServer()
+
+	now := time.Date(2019, 4, 24, 15, 0, 0, 0, Location)
+	events := make(chan WatchEvent, 10)
+	watcher := &watcher{
+		client:   client,
+		events:   events,
+		stations: make(map[int64]*watchedStation),
+	}
+	poll := func(offset time.Duration, expected ...WatchEventType) {
+		t.Helper()
+		client.Clock = FixedClock(now.Add(offset))
+		if !watcher.poll(context.Background(), 8002549) {
+			t.Fatal("expected poll to continue")
+		}
+		for _, eventType := range expected {
+			select {
+			case event := <-events:
+				if event.Type != eventType {
+					t.Errorf("expected %s, got %s", eventType, event.Type)
+				}
+			default:
+				t.Errorf("expected %s, got no event", eventType)
+			}
+		}
+		select {
+		case event := <-events:
+			t.Errorf("unexpected event %s", event.Type)
+		default:
+		}
+	}
+
+	poll(0)
+	// the recent changes fail, so the next poll resyncs
+	poll(30 * time.Second)
+	poll(time.Minute, WatchEventDelayChanged)
+	if stops := watcher.stations[8002549].stops; len(stops) != 1 {
+		t.Errorf("expected stops missing from the full changes to be dropped, got %d", len(stops))
+	}
+	poll(90*time.Second, WatchEventPlatformChanged)
+	// the recent changes no longer cover the time since the last poll
+	poll(5 * time.Minute)
+
+	if fullPolls != 3 || recentPolls != 2 {
+		t.Errorf("expected 3 full and 2 recent polls, got %d and %d", fullPolls, recentPolls)
+	}
+}